@@ -17,7 +17,8 @@ var cleanCmd = &cobra.Command{
 	Long: `Clean up all synced context from provider rules directories and config files.
 
 This command will:
-- Remove scratchpad copies from .claude/rules/ and .agent/rules/
+- Remove scratchpad copies and links from .claude/rules/ and .agent/rules/
+  (only the link is removed, never the scratchpad it points to)
 - Remove the managed context block from CLAUDE.md and AGENTS.md
 - Keep the original scratchpad in .aipad/ intact`,
 	Run: func(cmd *cobra.Command, args []string) {
@@ -36,6 +37,14 @@ This command will:
 
 		fmt.Println("Cleaning synced context...")
 
+		var paths []string
+		for name := range s.Providers {
+			paths = append(paths, providerPaths(s, name)...)
+		}
+		defer commitJournal(beginJournal("clean", args, paths...))
+
+		scratchpadPath := filepath.Join(cwd, state.AIPadDir, state.ScratchpadFile)
+
 		// Clean all provider rules directories and config files
 		for name, config := range s.Providers {
 			// Skip duplicates (ag is same as antigravity)
@@ -43,22 +52,29 @@ This command will:
 				continue
 			}
 
-			// Remove scratchpad from rules directory
-			rulesScrtachpad := filepath.Join(cwd, config.RulesDir, "scratchpad.md")
+			// Remove scratchpad from rules directory. os.Remove unlinks a
+			// symlink or hard link without touching the scratchpad itself.
+			link, err := syncpkg.InspectRulesLink(scratchpadPath, config.RulesDir)
+			if err != nil {
+				fmt.Printf("Warning: Could not inspect %s: %v\n", link.Path, err)
+			}
+			rulesScrtachpad := filepath.Join(cwd, link.Path)
 			if err := os.Remove(rulesScrtachpad); err != nil {
 				if !os.IsNotExist(err) {
 					fmt.Printf("Warning: Could not remove %s: %v\n", rulesScrtachpad, err)
 				}
+			} else if link.Linked() {
+				fmt.Printf("Removed %s (%s)\n", rulesScrtachpad, link.Mode)
 			} else {
 				fmt.Printf("Removed %s\n", rulesScrtachpad)
 			}
 
-			// Clear managed block from config file
+			// Clear managed blocks from config file
 			configPath := filepath.Join(cwd, config.ConfigFile)
-			if err := syncpkg.UpdateConfigWithManagedBlock(configPath, ""); err != nil {
+			if err := syncpkg.UpdateConfigBlocks(configPath, nil); err != nil {
 				fmt.Printf("Warning: Could not clear %s: %v\n", configPath, err)
 			} else {
-				fmt.Printf("Cleared managed block in %s\n", config.ConfigFile)
+				fmt.Printf("Cleared managed blocks in %s\n", config.ConfigFile)
 			}
 		}
 
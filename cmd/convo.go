@@ -1,36 +1,124 @@
 package cmd
 
 import (
+	"aipad/internal/author"
+	"aipad/internal/config"
 	"aipad/internal/crypto"
+	"aipad/internal/diff"
+	"aipad/internal/scratchpad"
 	"aipad/internal/state"
+	syncpkg "aipad/internal/sync"
+	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/spf13/cobra"
 )
 
 // convoCmd represents the convo command
 var convoCmd = &cobra.Command{
-	Use:   "convo \"<text>\"",
+	Use:   "convo [\"<text>\" | - | -f <file>]",
 	Short: "Add conversation context to the scratchpad",
 	Long: `Append conversation context to the scratchpad with a timestamp.
 The content is hashed and checked for duplicates before being added.
 
+Entries can be labelled with one or more tags, which are shown in the
+entry header and can be used to filter list, export and sync.
+
+Use --kind to classify an entry as a decision, todo, bug or note (default).
+Synced config files group entries by kind so agents see decisions, open
+work and known bugs before the general log.
+
+Each entry records the current provider, the git (or OS) user, and the
+agent that wrote it. Agents are detected from the environment variables
+their CLIs set, or from $AIPAD_AGENT; use --agent to mark an entry
+explicitly.
+
+Temporary notes can be given a lifetime with --ttl (e.g. 72h, 3d) or
+--until a date. Expired entries are left out of synced config files but
+stay in the scratchpad, list and export.
+
+Use --supersedes to replace an older entry whose guidance no longer holds.
+The old entry is kept but marked obsolete and left out of synced config
+files, and it does not count as a duplicate of the new one.
+
+Text that is at least 80% similar to an existing entry is skipped. Set
+"threshold" in .aipad/config.json to change this for the project, pass
+--threshold for a single call, or use --force to add the text anyway.
+Texts shorter than 32 characters ("min_length") are only rejected when
+they exactly match an entry.
+
+Hand-written rules in the config files of every provider count as well:
+each list item and paragraph outside the AIPad managed blocks is checked
+the same way, and the message names the file and line it matched.
+
+With --merge, a word diff against the similar entry is shown and you
+choose whether to replace it, append the new words to it, or keep both.
+Pass --merge=replace, append, keep or skip to decide without asking, or
+set "merge" in .aipad/config.json to change the default.
+
+The text can be passed as an argument, read from stdin with "-", read
+from a file with -f, or written in $EDITOR when no text is given.
+
 Example:
-  aipad convo "Discussed the new API design with focus on REST principles"`,
+  aipad convo "Discussed the new API design with focus on REST principles"
+  git log -1 --format=%B | aipad convo -
+  aipad convo -f notes.md
+  aipad convo
+  aipad convo --tag auth --tag db "Sessions are now stored in Postgres"
+  aipad convo --kind decision "We use gRPC for service-to-service calls"
+  aipad convo --supersedes 3f2a9c1d "We moved from REST to gRPC"
+  aipad convo --merge "We use Postgres 16 in production"
+  aipad convo --threshold 0.95 "Switched the CI runners to arm64"
+  aipad convo --until 2026-11-01 "Staging DB is down, use the local fixture"`,
 	Args: func(cmd *cobra.Command, args []string) error {
-		if len(args) != 1 {
-			return fmt.Errorf("requires exactly one argument: the conversation text")
+		if len(args) > 1 {
+			return fmt.Errorf("accepts at most one argument: the conversation text")
+		}
+		if len(args) == 1 && convoFile != "" {
+			return fmt.Errorf("cannot use both a text argument and --file")
 		}
-		if len(args[0]) == 0 {
+		if convoTTL != "" && convoUntil != "" {
+			return fmt.Errorf("cannot use both --ttl and --until")
+		}
+		if len(args) == 1 && len(args[0]) == 0 {
 			return fmt.Errorf("conversation text cannot be empty")
 		}
 		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
-		text := args[0]
+		text, err := readConvoText(args)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if text == "" {
+			fmt.Println("Error: conversation text cannot be empty")
+			os.Exit(1)
+		}
+
+		tags, err := scratchpad.NormalizeTags(convoTags)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		kind, err := scratchpad.ParseKind(convoKind)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		expiresAt, err := convoExpiry()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
 
 		// 1. Load existing state
 		s, err := state.Load()
@@ -38,66 +126,524 @@ Example:
 			fmt.Printf("Error: No active session found. Run 'aipad new <provider>' first.\n")
 			os.Exit(1)
 		}
+		defer commitJournal(beginJournal("convo", args, sessionPaths()...))
 
-		// 2. Generate hash for deduplication
+		// 2. Resolve the entries this one supersedes
+		var superseded []int
+		for _, id := range convoSupersedes {
+			i, err := s.FindEntry(id)
+			if err != nil {
+				fmt.Printf("Error: cannot supersede: %v\n", err)
+				exit(1)
+			}
+			superseded = append(superseded, i)
+		}
+
+		// 2.1 Generate hash for deduplication
 		hash := crypto.GenerateHash(text)
 
-		// 3. Check for duplicates (Exact Hash)
-		if crypto.IsDuplicate(hash, s.ContextHashes) {
-			fmt.Println("Duplicate content detected (exact match). Skipping addition.")
+		// 3. Check for duplicates (Exact Hash), ignoring the entries being superseded
+		if crypto.IsDuplicate(hash, withoutIndexes(s.ContextHashes, superseded)) {
+			fmt.Printf("Duplicate content detected (exact match with entry %s). Skipping addition.\n", s.Entries[duplicateIndex(s, hash, superseded)].ID)
 			return
 		}
 
+		settings, err := config.LoadSettings()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(1)
+		}
+		threshold, minLength, err := dedupLimits(cmd, settings, convoThreshold)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(1)
+		}
+
 		// 3.1 Check for duplicates (Fuzzy Match using History)
 		// We use the new ContextHistory field. If it's missing (legacy state), we skip this check or rely on hash.
-		if len(s.ContextHistory) > 0 {
-			isSimilar, similarContent, ratio := crypto.IsSimilar(text, s.ContextHistory, crypto.SimilarityThreshold)
+		// Short texts and --force skip this check; only exact duplicates are rejected then.
+		history := withoutIndexes(s.ContextHistory, superseded)
+		if !convoForce && len(history) > 0 && utf8.RuneCountInString(crypto.Normalize(text)) >= minLength {
+			matcher, err := similarityMatcher(settings, s.ContextHistory)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				exit(1)
+			}
+			if matcher.Indexable() {
+				matcher.Index = similarityIndex(s.ContextHistory)
+			}
+			isSimilar, similarContent, ratio := matcher.Similar(text, history, threshold)
 			if isSimilar {
-				fmt.Printf("Duplicate content detected (%.0f%% similar). Skipping addition.\nSimilar entry: \"%s...\"\n", ratio*100, truncate(similarContent, 50))
+				match := similarIndex(s, similarContent, superseded)
+				mode, err := convoMergeMode(cmd, settings)
+				if err != nil {
+					fmt.Printf("Error: %v\n", err)
+					exit(1)
+				}
+				if match < 0 {
+					mode = config.MergeSkip
+				}
+				if mode == config.MergeAsk {
+					fmt.Printf("Similar entry found (%.0f%% similar, id: %s):\n\n  %s\n\n", ratio*100, s.Entries[match].ID, diff.Format(diff.Words(similarContent, text)))
+					if mode, err = askMergeMode(); err != nil {
+						fmt.Printf("Error: %v\n", err)
+						exit(1)
+					}
+				}
+
+				switch mode {
+				case config.MergeReplace, config.MergeAppend:
+					if err := mergeIntoEntry(s, match, text, tags, mode); err != nil {
+						fmt.Printf("Error: %v\n", err)
+						exit(1)
+					}
+					return
+				case config.MergeKeep:
+					// Fall through and add the text as a new entry
+				default:
+					matchID := "unknown"
+					if match >= 0 {
+						matchID = s.Entries[match].ID
+					}
+					fmt.Printf("Duplicate content detected (%.0f%% similar to entry %s). Skipping addition.\nSimilar entry: \"%s...\"\nUse --force to add it anyway.\n", ratio*100, matchID, truncate(similarContent, 50))
+					return
+				}
+			}
+		}
+
+		// 3.2 Check the hand-written rules in every provider's config file.
+		// Exact repeats are always rejected; similar rules follow the same limits as entries.
+		fuzzy := !convoForce && utf8.RuneCountInString(crypto.Normalize(text)) >= minLength
+		rule, err := matchConfigRules(s, settings, text, threshold, fuzzy)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(1)
+		}
+		if rule != nil {
+			if rule.Exact {
+				fmt.Printf("Duplicate content detected (exact match with a hand-written rule in %s, line %d). Skipping addition.\n", rule.File, rule.Rule.Line)
 				return
 			}
+			fmt.Printf("Duplicate content detected (%.0f%% similar to a hand-written rule in %s, line %d). Skipping addition.\nSimilar rule: \"%s\"\nUse --force to add it anyway.\n", rule.Ratio*100, rule.File, rule.Rule.Line, truncate(rule.Rule.Text, 50))
+			return
 		}
 
 		// 4. Append to scratchpad.md
 		cwd, err := os.Getwd()
 		if err != nil {
 			fmt.Printf("Error getting current directory: %v\n", err)
-			os.Exit(1)
+			exit(1)
 		}
 		scratchpadPath := filepath.Join(cwd, state.AIPadDir, state.ScratchpadFile)
 
+		// Record the entry in state first so it gets a durable ID
+		meta := s.AddEntry(text, hash)
+		meta.Kind = kind
+		meta.Tags = tags
+
+		agent := convoAgent
+		if agent == agentFromProvider {
+			agent = s.CurrentProvider
+		}
+		by := author.Detect(s.CurrentProvider, agent)
+		meta.Provider = by.Provider
+		meta.Author = by.User
+		meta.Agent = by.Agent
+		meta.ExpiresAt = expiresAt
+		for _, i := range superseded {
+			meta.Supersedes = append(meta.Supersedes, s.Entries[i].ID)
+		}
+		id := meta.ID
+
 		// Format the entry
-		timestamp := time.Now().Format("2006-01-02 15:04:05")
-		entry := fmt.Sprintf("\n## [%s] Context Update\n%s\n---\n", timestamp, text)
+		e := scratchpad.Entry{
+			ID:        meta.ID,
+			Timestamp: meta.CreatedAt.Format(scratchpad.TimestampFormat),
+			Kind:      meta.Kind,
+			Tags:      meta.Tags,
+			Provider:  meta.Provider,
+			Author:    meta.Author,
+			Agent:     meta.Agent,
+			Content:   text,
+
+			Supersedes: meta.Supersedes,
+		}
+		if expiresAt != nil {
+			e.Expires = *expiresAt
+		}
+		entry := scratchpad.Format(e)
 
 		// Append to file
 		f, err := os.OpenFile(scratchpadPath, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644)
 		if err != nil {
 			fmt.Printf("Error opening scratchpad: %v\n", err)
-			os.Exit(1)
+			exit(1)
 		}
-		defer f.Close()
-
 		if _, err := f.WriteString(entry); err != nil {
 			fmt.Printf("Error writing to scratchpad: %v\n", err)
-			os.Exit(1)
+			exit(1)
+		}
+
+		f.Close()
+
+		// 4.1 Mark superseded entries as obsolete
+		if len(superseded) > 0 {
+			if err := markSuperseded(scratchpadPath, s, superseded, id); err != nil {
+				fmt.Printf("Error marking superseded entries: %v\n", err)
+				exit(1)
+			}
 		}
 
-		// 5. Update state with new hash and content
-		s.ContextHashes = append(s.ContextHashes, hash)
-		s.ContextHistory = append(s.ContextHistory, text) // Store full text for fuzzy matching
+		// 5. Save state with the new entry's hash and content
 		s.LastSync = time.Now()
 		if err := s.Save(); err != nil {
 			fmt.Printf("Error saving state: %v\n", err)
-			os.Exit(1)
+			exit(1)
 		}
 
-		fmt.Println("Context added to scratchpad.")
+		fmt.Printf("Context added to scratchpad. (id: %s)\n", id)
+		for _, i := range superseded {
+			fmt.Printf("Superseded entry %s.\n", s.Entries[i].ID)
+		}
 	},
 }
 
+var (
+	convoTags  []string
+	convoKind  string
+	convoFile  string
+	convoAgent string
+	convoTTL   string
+	convoUntil string
+
+	convoSupersedes []string
+	convoMerge      string
+	convoThreshold  float64
+	convoForce      bool
+)
+
+// agentFromProvider is the value of a bare --agent flag, standing in for the current provider
+const agentFromProvider = "provider"
+
+// convoEditorTemplate is appended below the text when convo opens $EDITOR.
+// Everything from convoEditorMarker onwards is stripped from the saved entry.
+const convoEditorMarker = "<!-- aipad:"
+
+const convoEditorTemplate = "\n\n" + convoEditorMarker + `
+Write the context to save above this comment. Markdown is supported.
+This comment is removed when the entry is saved.
+Save an empty entry to abort.
+-->
+`
+
+// markSuperseded records in state and in the scratchpad that the entries at
+// the given indexes were replaced by the entry with ID by
+func markSuperseded(scratchpadPath string, s *state.State, indexes []int, by string) error {
+	content, err := os.ReadFile(scratchpadPath)
+	if err != nil {
+		return err
+	}
+	updated := string(content)
+	entries := scratchpad.Parse(updated)
+
+	for _, i := range indexes {
+		s.Entries[i].SupersededBy = by
+		old, ok := scratchpad.Find(entries, s.Entries[i].ID)
+		if !ok {
+			continue // Already gone from the scratchpad; state still records it
+		}
+		old.SupersededBy = by
+		if updated, err = scratchpad.Replace(updated, old); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(scratchpadPath, []byte(updated), 0644)
+}
+
+// configRule is a hand-written rule in a provider config file that new text duplicates
+type configRule struct {
+	File  string
+	Rule  syncpkg.Rule
+	Ratio float64
+	Exact bool
+}
+
+// matchConfigRules checks text against the rules people wrote by hand in the
+// config files of every configured provider, outside the managed blocks. It
+// returns an exact match if there is one, otherwise the most similar rule that
+// reaches threshold when fuzzy is set, or nil.
+func matchConfigRules(s *state.State, settings *config.Settings, text string, threshold float64, fuzzy bool) (*configRule, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+
+	// Several providers can share a config file; read each one once
+	var files []string
+	for _, p := range s.Providers {
+		if p.ConfigFile != "" && !slices.Contains(files, p.ConfigFile) {
+			files = append(files, p.ConfigFile)
+		}
+	}
+	slices.Sort(files)
+
+	var rules []configRule
+	for _, file := range files {
+		content, err := os.ReadFile(filepath.Join(cwd, file))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		for _, r := range syncpkg.HumanRules(string(content)) {
+			rules = append(rules, configRule{File: file, Rule: r})
+		}
+	}
+
+	hash := crypto.GenerateHash(text)
+	for _, r := range rules {
+		if crypto.GenerateHash(r.Rule.Text) == hash {
+			r.Ratio, r.Exact = 1.0, true
+			return &r, nil
+		}
+	}
+	if !fuzzy || len(rules) == 0 {
+		return nil, nil
+	}
+
+	corpus := make([]string, len(rules))
+	for i, r := range rules {
+		corpus[i] = r.Rule.Text
+	}
+	matcher, err := similarityMatcher(settings, corpus)
+	if err != nil {
+		return nil, err
+	}
+	var best *configRule
+	for i := range rules {
+		if ratio, ok := matcher.Compare(text, rules[i].Rule.Text, threshold); ok && (best == nil || ratio > best.Ratio) {
+			best = &rules[i]
+			best.Ratio = ratio
+		}
+	}
+	return best, nil
+}
+
+// similarIndex returns the index of the entry whose history matched content,
+// skipping the entries being superseded
+func similarIndex(s *state.State, content string, superseded []int) int {
+	for i, h := range s.ContextHistory {
+		if h == content && !slices.Contains(superseded, i) {
+			return i
+		}
+	}
+	return -1
+}
+
+// duplicateIndex returns the index of the entry with the given hash,
+// skipping the entries being superseded
+func duplicateIndex(s *state.State, hash string, superseded []int) int {
+	for i, h := range s.ContextHashes {
+		if h == hash && !slices.Contains(superseded, i) {
+			return i
+		}
+	}
+	return -1
+}
+
+// convoMergeMode returns what to do with near-duplicate text: --merge wins over
+// the merge setting in .aipad/config.json, and the default is to skip
+func convoMergeMode(cmd *cobra.Command, settings *config.Settings) (string, error) {
+	if cmd.Flags().Changed("merge") {
+		return convoMerge, config.ValidateMerge(convoMerge)
+	}
+	if settings.Merge == "" {
+		return config.MergeSkip, nil
+	}
+	return settings.Merge, nil
+}
+
+// askMergeMode prompts for how to merge near-duplicate text
+func askMergeMode() (string, error) {
+	if !stdinIsTerminal() {
+		return "", fmt.Errorf("cannot ask how to merge without a terminal; use --merge=replace, append, keep or skip")
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		fmt.Print("[r]eplace the old entry, [a]ppend the new words to it, [k]eep both, or [s]kip? ")
+		answer, err := reader.ReadString('\n')
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "r", "replace":
+			return config.MergeReplace, nil
+		case "a", "append":
+			return config.MergeAppend, nil
+		case "k", "keep":
+			return config.MergeKeep, nil
+		case "s", "skip":
+			return config.MergeSkip, nil
+		}
+		if err != nil {
+			fmt.Println()
+			return config.MergeSkip, nil
+		}
+	}
+}
+
+// mergeIntoEntry folds text into the entry at index i, either replacing its
+// content or appending the words text adds to it, and merges in the new tags
+func mergeIntoEntry(s *state.State, i int, text string, tags []string, mode string) error {
+	scratchpadPath, err := state.GetScratchpadPath()
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(scratchpadPath)
+	if err != nil {
+		return fmt.Errorf("failed to read scratchpad: %w", err)
+	}
+	id := s.Entries[i].ID
+	entry, ok := scratchpad.Find(scratchpad.Parse(string(content)), id)
+	if !ok {
+		return fmt.Errorf("entry '%s' not found in scratchpad", id)
+	}
+
+	merged := text
+	if mode == config.MergeAppend {
+		delta := diff.Inserted(diff.Words(entry.Content, text))
+		if len(delta) == 0 {
+			fmt.Printf("Nothing new to append to entry %s. Skipping addition.\n", id)
+			return nil
+		}
+		merged = entry.Content + "\n\n" + strings.Join(delta, " ")
+	}
+
+	entry.Content = merged
+	for _, tag := range tags {
+		if !entry.HasTag(tag) {
+			entry.Tags = append(entry.Tags, tag)
+		}
+	}
+	updated, err := scratchpad.Replace(string(content), entry)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(scratchpadPath, []byte(updated), 0644); err != nil {
+		return fmt.Errorf("failed to write scratchpad: %w", err)
+	}
+
+	s.UpdateEntry(i, merged, crypto.GenerateHash(merged))
+	s.Entries[i].Tags = entry.Tags
+	if err := s.Save(); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+
+	if mode == config.MergeAppend {
+		fmt.Printf("Appended to entry %s.\n", id)
+	} else {
+		fmt.Printf("Replaced entry %s.\n", id)
+	}
+	return nil
+}
+
+// withoutIndexes returns values minus the elements at the given indexes
+func withoutIndexes(values []string, indexes []int) []string {
+	if len(indexes) == 0 {
+		return values
+	}
+	var result []string
+	for i, v := range values {
+		if !slices.Contains(indexes, i) {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// convoExpiry returns when the new entry expires according to --ttl or --until, or nil
+func convoExpiry() (*time.Time, error) {
+	var expiresAt time.Time
+	switch {
+	case convoTTL != "":
+		ttl, err := parseTTL(convoTTL)
+		if err != nil {
+			return nil, err
+		}
+		expiresAt = time.Now().Add(ttl)
+	case convoUntil != "":
+		t, err := scratchpad.ParseTime(convoUntil)
+		if err != nil {
+			return nil, err
+		}
+		expiresAt = t
+	default:
+		return nil, nil
+	}
+	// The scratchpad header stores seconds, so keep state in step with it
+	expiresAt = expiresAt.Truncate(time.Second)
+	return &expiresAt, nil
+}
+
+// parseTTL parses a Go duration, additionally accepting whole days such as "3d"
+func parseTTL(value string) (time.Duration, error) {
+	var ttl time.Duration
+	var err error
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		var n int
+		n, err = strconv.Atoi(days)
+		ttl = time.Duration(n) * 24 * time.Hour
+	} else {
+		ttl, err = time.ParseDuration(value)
+	}
+	if err != nil || ttl <= 0 {
+		return 0, fmt.Errorf("invalid ttl '%s': use a positive duration such as 72h or 3d", value)
+	}
+	return ttl, nil
+}
+
+// readConvoText returns the entry text from the argument, stdin, --file or $EDITOR
+func readConvoText(args []string) (string, error) {
+	switch {
+	case len(args) == 1 && args[0] == "-":
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("failed to read stdin: %w", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	case len(args) == 1:
+		return args[0], nil
+	case convoFile != "":
+		data, err := os.ReadFile(convoFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", convoFile, err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	default:
+		text, err := editText(convoEditorTemplate)
+		if err != nil {
+			return "", err
+		}
+		if i := strings.Index(text, convoEditorMarker); i >= 0 {
+			text = text[:i]
+		}
+		return strings.TrimSpace(text), nil
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(convoCmd)
+	convoCmd.Flags().StringSliceVar(&convoTags, "tag", nil, "label the entry with a tag (repeatable)")
+	convoCmd.Flags().StringVarP(&convoFile, "file", "f", "", "read the conversation text from a file")
+	convoCmd.Flags().StringVar(&convoAgent, "agent", "", "mark the entry as written by this agent (defaults to the current provider)")
+	convoCmd.Flags().Lookup("agent").NoOptDefVal = agentFromProvider
+	convoCmd.Flags().StringVar(&convoTTL, "ttl", "", "stop syncing the entry after this long, e.g. 72h or 3d")
+	convoCmd.Flags().StringVar(&convoUntil, "until", "", "stop syncing the entry at this date, e.g. 2026-11-01")
+	convoCmd.Flags().StringSliceVar(&convoSupersedes, "supersedes", nil, "mark an older entry as replaced by this one (repeatable)")
+	convoCmd.Flags().StringVar(&convoMerge, "merge", "", "when the text is similar to an entry: ask, replace, append, keep or skip")
+	convoCmd.Flags().Lookup("merge").NoOptDefVal = config.MergeAsk
+	convoCmd.Flags().Float64Var(&convoThreshold, "threshold", crypto.SimilarityThreshold, "similarity from which the text counts as a near-duplicate (0-1]")
+	convoCmd.Flags().BoolVar(&convoForce, "force", false, "add the text even if it is similar to an existing entry")
+	convoCmd.Flags().StringVar(&convoKind, "kind", scratchpad.KindNote, "entry kind: decision, todo, bug or note")
 }
 
 func truncate(text string, length int) string {
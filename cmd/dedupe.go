@@ -0,0 +1,424 @@
+package cmd
+
+import (
+	"aipad/internal/config"
+	"aipad/internal/crypto"
+	"aipad/internal/diff"
+	"aipad/internal/scratchpad"
+	"aipad/internal/state"
+	syncpkg "aipad/internal/sync"
+	"bufio"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+)
+
+// Automatic merge policies for dedupe --auto
+const (
+	keepNewest = "keep-newest"
+	keepOldest = "keep-oldest"
+)
+
+var (
+	dedupeAuto      string
+	dedupeCheck     string
+	dedupeThreshold float64
+	dedupeLimit     int
+)
+
+// dedupeCmd represents the dedupe command
+var dedupeCmd = &cobra.Command{
+	Use:   "dedupe",
+	Short: "Find and merge near-duplicate entries",
+	Long: `Audit the scratchpad for near-duplicate entries, including entries
+added before fuzzy matching existed or edited into scratchpad.md by hand.
+
+Entries are grouped into clusters of similar entries using the project's
+similarity settings, and each cluster is listed with the similarity of
+every entry to the newest one. In a terminal you choose which entry of
+each cluster to keep; with --auto keep-newest (or keep-oldest) the choice
+is made for you. The other entries are removed: words they add to the kept
+entry are appended to it, as 'aipad convo --merge=append' does, and their
+tags, pin and supersedes links are merged into it.
+
+Superseded entries are left alone.
+
+Use --check to see the entries nearest to a text without changing
+anything.
+
+Example:
+  aipad dedupe
+  aipad dedupe --auto keep-newest
+  aipad dedupe --threshold 0.9
+  aipad dedupe --check "Sessions are stored in Postgres"`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if dedupeAuto != "" && dedupeAuto != keepNewest && dedupeAuto != keepOldest {
+			fmt.Printf("Error: invalid --auto policy '%s': must be %s or %s\n", dedupeAuto, keepNewest, keepOldest)
+			os.Exit(1)
+		}
+
+		// 1. Load existing state and settings
+		s, err := state.Load()
+		if err != nil {
+			fmt.Printf("Error: No active session found. Run 'aipad new <provider>' first.\n")
+			os.Exit(1)
+		}
+		settings, err := config.LoadSettings()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		threshold, minLength, err := dedupLimits(cmd, settings, dedupeThreshold)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		// 2. Read the current entries from the scratchpad
+		scratchpadPath, err := state.GetScratchpadPath()
+		if err != nil {
+			fmt.Printf("Error getting current directory: %v\n", err)
+			os.Exit(1)
+		}
+		content, err := os.ReadFile(scratchpadPath)
+		if err != nil {
+			fmt.Printf("Error reading scratchpad: %v\n", err)
+			os.Exit(1)
+		}
+		all := scratchpad.Parse(string(content))
+		superseded := syncpkg.Superseded(all)
+		var entries []scratchpad.Entry
+		for _, e := range all {
+			if !superseded[e.ID] {
+				entries = append(entries, e)
+			}
+		}
+
+		contents := make([]string, len(entries))
+		for i, e := range entries {
+			contents[i] = e.Content
+		}
+		matcher, err := similarityMatcher(settings, contents)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if matcher.Indexable() {
+			matcher.Index = crypto.NewIndex()
+			matcher.Index.Update(contents)
+		}
+
+		// 3. --check only reports the nearest entries
+		if dedupeCheck != "" {
+			printNearest(matcher, entries, dedupeCheck, threshold)
+			return
+		}
+
+		// 4. Cluster the entries and report each cluster
+		clusters := clusterEntries(matcher, entries, threshold, minLength)
+		if len(clusters) == 0 {
+			fmt.Println("No near-duplicate entries found.")
+			return
+		}
+		for n, cluster := range clusters {
+			printCluster(matcher, n+1, cluster)
+		}
+
+		if dedupeAuto == "" && !stdinIsTerminal() {
+			fmt.Printf("Found %d cluster(s). Run 'aipad dedupe' in a terminal or pass --auto %s to merge them.\n", len(clusters), keepNewest)
+			return
+		}
+
+		// 5. Merge the clusters
+		defer commitJournal(beginJournal("dedupe", args, append(sessionPaths(), providerPaths(s, s.CurrentProvider)...)...))
+
+		if dedupeAuto == "" {
+			fmt.Println("Words the other entries of a cluster add are appended to the entry you keep.")
+		}
+		reader := bufio.NewReader(os.Stdin)
+		updated := string(content)
+		removed := 0
+		for n, cluster := range clusters {
+			var keep int
+			switch dedupeAuto {
+			case keepNewest:
+				keep = newestEntry(cluster)
+			case keepOldest:
+				keep = oldestEntry(cluster)
+			default:
+				var ok bool
+				if keep, ok = askKeep(reader, n+1, cluster); !ok {
+					continue
+				}
+			}
+
+			if updated, err = mergeCluster(updated, s, cluster, keep); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				exit(1)
+			}
+			removed += len(cluster) - 1
+			fmt.Printf("Cluster %d: kept %s, removed %d duplicate(s).\n", n+1, cluster[keep].ID, len(cluster)-1)
+		}
+		if removed == 0 {
+			fmt.Println("No changes made.")
+			return
+		}
+
+		// 6. Write the scratchpad and state, recording entries that were only in the scratchpad
+		reconcileState(s, scratchpad.Parse(updated))
+		if err := os.WriteFile(scratchpadPath, []byte(updated), 0644); err != nil {
+			fmt.Printf("Error writing to scratchpad: %v\n", err)
+			exit(1)
+		}
+		if err := s.Save(); err != nil {
+			fmt.Printf("Error saving state: %v\n", err)
+			exit(1)
+		}
+		fmt.Printf("Removed %d duplicate(s).\n", removed)
+
+		// 7. Re-sync the current provider
+		if err := syncProvider(s, s.CurrentProvider); err != nil {
+			fmt.Printf("Warning: Could not sync %s: %v\n", s.CurrentProvider, err)
+			return
+		}
+		fmt.Printf("Synced %s.\n", s.CurrentProvider)
+	},
+}
+
+// clusterEntries groups entries whose similarity reaches threshold, joining
+// clusters through any similar pair. Entries shorter than minLength only
+// join entries they exactly match. Clusters and their members keep
+// scratchpad order; entries without a near-duplicate are left out.
+func clusterEntries(matcher *crypto.Matcher, entries []scratchpad.Entry, threshold float64, minLength int) [][]scratchpad.Entry {
+	parent := make([]int, len(entries))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	short := make([]bool, len(entries))
+	for i, e := range entries {
+		short[i] = utf8.RuneCountInString(crypto.Normalize(e.Content)) < minLength
+	}
+
+	for i := range entries {
+		for j := i + 1; j < len(entries); j++ {
+			if find(i) == find(j) {
+				continue
+			}
+			similar := false
+			if short[i] || short[j] {
+				similar = crypto.Normalize(entries[i].Content) == crypto.Normalize(entries[j].Content)
+			} else {
+				_, similar = matcher.Compare(entries[i].Content, entries[j].Content, threshold)
+			}
+			if similar {
+				parent[find(j)] = find(i)
+			}
+		}
+	}
+
+	groups := make(map[int][]scratchpad.Entry)
+	var roots []int
+	for i, e := range entries {
+		root := find(i)
+		if _, ok := groups[root]; !ok {
+			roots = append(roots, root)
+		}
+		groups[root] = append(groups[root], e)
+	}
+
+	var clusters [][]scratchpad.Entry
+	for _, root := range roots {
+		if len(groups[root]) > 1 {
+			clusters = append(clusters, groups[root])
+		}
+	}
+	return clusters
+}
+
+// newestEntry returns the index of the most recent entry in the cluster.
+// Entries with equal or unreadable timestamps are ordered by position.
+func newestEntry(cluster []scratchpad.Entry) int {
+	newest := 0
+	for i := 1; i < len(cluster); i++ {
+		if !entryBefore(cluster[i], cluster[newest]) {
+			newest = i
+		}
+	}
+	return newest
+}
+
+// oldestEntry returns the index of the earliest entry in the cluster
+func oldestEntry(cluster []scratchpad.Entry) int {
+	oldest := 0
+	for i := 1; i < len(cluster); i++ {
+		if entryBefore(cluster[i], cluster[oldest]) {
+			oldest = i
+		}
+	}
+	return oldest
+}
+
+// entryBefore reports whether a was written before b according to their timestamps
+func entryBefore(a, b scratchpad.Entry) bool {
+	ta, errA := scratchpad.ParseTime(a.Timestamp)
+	tb, errB := scratchpad.ParseTime(b.Timestamp)
+	return errA == nil && errB == nil && ta.Before(tb)
+}
+
+func printCluster(matcher *crypto.Matcher, n int, cluster []scratchpad.Entry) {
+	newest := newestEntry(cluster)
+	fmt.Printf("Cluster %d (%d entries):\n", n, len(cluster))
+	for i, e := range cluster {
+		score := "newest"
+		if i != newest {
+			score = fmt.Sprintf("%.0f%%", matcher.Ratio(cluster[newest].Content, e.Content)*100)
+		}
+		fmt.Printf("  %s  [%s]  %-6s  \"%s\"\n", e.ID, e.Timestamp, score, truncate(oneLine(e.Content), 60))
+	}
+	fmt.Println()
+}
+
+// printNearest lists the entries most similar to text, marking those that
+// would be rejected as near-duplicates
+func printNearest(matcher *crypto.Matcher, entries []scratchpad.Entry, text string, threshold float64) {
+	type scored struct {
+		entry scratchpad.Entry
+		ratio float64
+	}
+	var results []scored
+	for _, e := range entries {
+		results = append(results, scored{e, matcher.Ratio(text, e.Content)})
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].ratio > results[j].ratio })
+
+	if len(results) == 0 {
+		fmt.Println("The scratchpad has no entries to compare with.")
+		return
+	}
+	if dedupeLimit > 0 && len(results) > dedupeLimit {
+		results = results[:dedupeLimit]
+	}
+	fmt.Printf("Nearest entries (near-duplicate at %.0f%% or more):\n", threshold*100)
+	for _, r := range results {
+		mark := " "
+		if r.ratio >= threshold {
+			mark = "*"
+		}
+		fmt.Printf("%s %4.0f%%  %s  \"%s\"\n", mark, r.ratio*100, r.entry.ID, truncate(oneLine(r.entry.Content), 60))
+	}
+}
+
+// askKeep prompts for the entry of a cluster to keep. It reports false to skip the cluster.
+func askKeep(reader *bufio.Reader, n int, cluster []scratchpad.Entry) (int, bool) {
+	newest, oldest := newestEntry(cluster), oldestEntry(cluster)
+	for {
+		fmt.Printf("Cluster %d: keep [n]ewest (%s), [o]ldest (%s), an entry ID, or [s]kip? ", n, cluster[newest].ID, cluster[oldest].ID)
+		answer, err := reader.ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		switch answer {
+		case "n", "newest":
+			return newest, true
+		case "o", "oldest":
+			return oldest, true
+		case "s", "skip":
+			return 0, false
+		}
+		if answer != "" {
+			for i, e := range cluster {
+				if strings.HasPrefix(e.ID, answer) {
+					return i, true
+				}
+			}
+		}
+		if err != nil {
+			fmt.Println()
+			return 0, false
+		}
+	}
+}
+
+// mergeCluster keeps cluster[keep] and removes the other entries from content
+// and state. The words each removed entry adds to the kept one are appended
+// to it, so no text is lost. The kept entry inherits their tags, pin and
+// supersedes links, and entries they superseded point at the kept entry instead. The state records
+// of the remaining entries are brought up to date by reconcileState.
+func mergeCluster(content string, s *state.State, cluster []scratchpad.Entry, keep int) (string, error) {
+	kept, ok := scratchpad.Find(scratchpad.Parse(content), cluster[keep].ID)
+	if !ok {
+		return "", fmt.Errorf("entry '%s' not found in scratchpad", cluster[keep].ID)
+	}
+
+	var dropped []string
+	var err error
+	for i, e := range cluster {
+		if i == keep {
+			continue
+		}
+		dropped = append(dropped, e.ID)
+		if delta := diff.Inserted(diff.Words(kept.Content, e.Content)); len(delta) > 0 {
+			kept.Content += "\n\n" + strings.Join(delta, " ")
+		}
+		for _, tag := range e.Tags {
+			if !kept.HasTag(tag) {
+				kept.Tags = append(kept.Tags, tag)
+			}
+		}
+		kept.Pinned = kept.Pinned || e.Pinned
+		for _, id := range e.Supersedes {
+			if id != kept.ID && !slices.Contains(kept.Supersedes, id) {
+				kept.Supersedes = append(kept.Supersedes, id)
+			}
+		}
+
+		if content, err = scratchpad.Remove(content, e.ID); err != nil {
+			return "", err
+		}
+		if j := s.EntryIndex(e.ID); j >= 0 {
+			s.RemoveEntry(j)
+		}
+	}
+
+	// Entries superseded by a removed entry are now superseded by the kept one
+	for _, e := range scratchpad.Parse(content) {
+		if e.ID != kept.ID && slices.Contains(dropped, e.SupersededBy) {
+			e.SupersededBy = kept.ID
+			if content, err = scratchpad.Replace(content, e); err != nil {
+				return "", err
+			}
+		}
+	}
+	for j := range s.Entries {
+		if slices.Contains(dropped, s.Entries[j].SupersededBy) {
+			s.Entries[j].SupersededBy = kept.ID
+		}
+	}
+
+	return scratchpad.Replace(content, kept)
+}
+
+// oneLine collapses whitespace so multi-line entries fit on one line
+func oneLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+func init() {
+	rootCmd.AddCommand(dedupeCmd)
+	dedupeCmd.Flags().StringVar(&dedupeAuto, "auto", "", "merge without asking: keep-newest or keep-oldest")
+	dedupeCmd.Flags().StringVar(&dedupeCheck, "check", "", "show the entries nearest to this text without changing anything")
+	dedupeCmd.Flags().Float64Var(&dedupeThreshold, "threshold", crypto.SimilarityThreshold, "similarity from which entries count as near-duplicates (0-1]")
+	dedupeCmd.Flags().IntVarP(&dedupeLimit, "limit", "n", 5, "number of entries --check shows (0 for all)")
+}
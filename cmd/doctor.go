@@ -0,0 +1,366 @@
+package cmd
+
+import (
+	"aipad/internal/config"
+	"aipad/internal/crypto"
+	"aipad/internal/scratchpad"
+	"aipad/internal/state"
+	syncpkg "aipad/internal/sync"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var doctorFix bool
+
+// doctorCmd represents the doctor command
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose and repair broken markers and provider setups",
+	Long: `Check the session and every enabled provider for problems that make
+syncs go wrong:
+- state.json records that do not match the scratchpad
+- Managed block markers that are unterminated, out of order, nested or
+  duplicated, which sync would leave behind as stray text
+- Config files and rules directories that are missing or not writable
+- Rules directory copies of the scratchpad that are out of date
+- Custom providers whose files are outside the repository
+
+Use --fix to apply the repairs that cannot lose text: state.json is
+brought in line with the scratchpad, stray markers and exact duplicate
+blocks are removed (the text between them stays), missing rules
+directories are created and stale copies are refreshed, after folding
+their hand edits into the scratchpad. Everything else is reported with
+what to do by hand. The command exits non-zero while problems remain.
+
+Example:
+  aipad doctor
+  aipad doctor --fix`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// 1. Load existing state
+		s, err := state.Load()
+		if err != nil {
+			fmt.Printf("Error: No active session found. Run 'aipad new <provider>' first.\n")
+			os.Exit(1)
+		}
+		cwd, err := os.Getwd()
+		if err != nil {
+			fmt.Printf("Error getting current directory: %v\n", err)
+			os.Exit(1)
+		}
+
+		// 2. Diagnose the session, then every provider
+		diagnoses := diagnoseSession(s, cwd)
+		for _, t := range syncTargets(s) {
+			diagnoses = append(diagnoses, diagnoseTarget(s, cwd, t)...)
+		}
+		if len(diagnoses) == 0 {
+			fmt.Println("No problems found.")
+			return
+		}
+
+		// 3. Report the problems
+		if !doctorFix {
+			fixable := 0
+			for _, d := range diagnoses {
+				if d.Fix != nil {
+					fixable++
+					fmt.Printf("%s: %s (fixable)\n", d.Where, d.Problem)
+				} else {
+					fmt.Printf("%s: %s\n", d.Where, d.Problem)
+				}
+			}
+			fmt.Printf("\n%d problem(s) found", len(diagnoses))
+			if fixable > 0 {
+				fmt.Printf(", %d fixable. Run 'aipad doctor --fix' to repair them", fixable)
+			}
+			fmt.Println(".")
+			os.Exit(1)
+		}
+
+		// 4. Apply the safe repairs
+		paths := sessionPaths()
+		for _, name := range s.EnabledProviders() {
+			paths = append(paths, providerPaths(s, name)...)
+		}
+		op := beginJournal("doctor", args, paths...)
+		remaining := 0
+		for _, d := range diagnoses {
+			if d.Fix == nil {
+				fmt.Printf("%s: %s\n", d.Where, d.Problem)
+				remaining++
+				continue
+			}
+			done, err := d.Fix()
+			if err != nil {
+				fmt.Printf("%s: %s (could not fix: %v)\n", d.Where, d.Problem, err)
+				remaining++
+				continue
+			}
+			if done != "" {
+				fmt.Printf("Fixed %s: %s\n", d.Where, done)
+			}
+		}
+		commitJournal(op)
+
+		if remaining > 0 {
+			fmt.Printf("\n%d problem(s) need fixing by hand.\n", remaining)
+			os.Exit(1)
+		}
+		fmt.Println("\nAll problems fixed.")
+	},
+}
+
+// diagnosis is a problem found by doctor
+type diagnosis struct {
+	// Where is the file, or file and line, the problem is in
+	Where   string
+	Problem string
+	// Fix repairs the problem and describes what it did, or returns "" if an
+	// earlier fix already did. It is nil for problems only a person can fix.
+	Fix func() (string, error)
+}
+
+// once makes a repair shared by several diagnoses run only the first time
+func once(fix func() (string, error)) func() (string, error) {
+	ran := false
+	return func() (string, error) {
+		if ran {
+			return "", nil
+		}
+		ran = true
+		return fix()
+	}
+}
+
+// diagnoseSession checks the current provider and that state.json matches the scratchpad
+func diagnoseSession(s *state.State, cwd string) []diagnosis {
+	var diagnoses []diagnosis
+	statePath := filepath.Join(state.AIPadDir, state.StateType)
+	scratchpadRel := filepath.Join(state.AIPadDir, state.ScratchpadFile)
+
+	if _, ok := s.Providers[s.CurrentProvider]; !ok {
+		diagnoses = append(diagnoses, diagnosis{Where: statePath, Problem: fmt.Sprintf("current provider '%s' is not configured; run 'aipad use <provider>'", s.CurrentProvider)})
+	}
+
+	content, err := os.ReadFile(filepath.Join(cwd, scratchpadRel))
+	if err != nil {
+		return append(diagnoses, diagnosis{Where: scratchpadRel, Problem: fmt.Sprintf("cannot be read (%v); restore it with 'aipad undo' or from version control", err)})
+	}
+	entries := scratchpad.Parse(string(content))
+
+	var seen []string
+	for _, e := range entries {
+		if slices.Contains(seen, e.ID) {
+			diagnoses = append(diagnoses, diagnosis{Where: scratchpadRel, Problem: fmt.Sprintf("ID %s is used by more than one entry; remove or re-ID one of them", e.ID)})
+		}
+		seen = append(seen, e.ID)
+	}
+
+	fix := once(func() (string, error) {
+		return repairState(s, cwd)
+	})
+	if len(s.ContextHistory) != len(s.ContextHashes) || len(s.Entries) != len(s.ContextHashes) {
+		problem := fmt.Sprintf("entry records are misaligned (%d hashes, %d texts, %d entries)", len(s.ContextHashes), len(s.ContextHistory), len(s.Entries))
+		return append(diagnoses, diagnosis{Where: statePath, Problem: problem, Fix: fix})
+	}
+	for _, e := range entries {
+		i := s.EntryIndex(e.ID)
+		switch {
+		case i < 0:
+			diagnoses = append(diagnoses, diagnosis{Where: statePath, Problem: fmt.Sprintf("entry %s is in the scratchpad but not in state.json", e.ID), Fix: fix})
+		case s.ContextHashes[i] != crypto.GenerateHash(e.Content):
+			diagnoses = append(diagnoses, diagnosis{Where: statePath, Problem: fmt.Sprintf("entry %s was edited in the scratchpad but not in state.json", e.ID), Fix: fix})
+		}
+	}
+	for _, e := range s.Entries {
+		if !slices.Contains(seen, e.ID) {
+			diagnoses = append(diagnoses, diagnosis{Where: statePath, Problem: fmt.Sprintf("entry %s is in state.json but not in the scratchpad", e.ID), Fix: fix})
+		}
+	}
+	return diagnoses
+}
+
+// repairState brings state.json in line with the scratchpad, which is the
+// source of truth: misaligned records are rebuilt from it, entries missing
+// from it are dropped, and the rest are reconciled as 'aipad fmt' does
+func repairState(s *state.State, cwd string) (string, error) {
+	content, err := os.ReadFile(filepath.Join(cwd, state.AIPadDir, state.ScratchpadFile))
+	if err != nil {
+		return "", fmt.Errorf("failed to read scratchpad: %w", err)
+	}
+	entries := scratchpad.Parse(string(content))
+
+	var done []string
+	if len(s.ContextHistory) != len(s.ContextHashes) || len(s.Entries) != len(s.ContextHashes) {
+		s.ContextHashes, s.ContextHistory, s.Entries = []string{}, []string{}, []state.Entry{}
+		done = append(done, "rebuilt the entry records from the scratchpad")
+	}
+	dropped := 0
+	for i := len(s.Entries) - 1; i >= 0; i-- {
+		if _, ok := scratchpad.Find(entries, s.Entries[i].ID); !ok {
+			s.RemoveEntry(i)
+			dropped++
+		}
+	}
+	if dropped > 0 {
+		done = append(done, fmt.Sprintf("dropped %d entry record(s) missing from the scratchpad", dropped))
+	}
+	updated, added := reconcileState(s, entries)
+	if updated > 0 {
+		done = append(done, fmt.Sprintf("updated %d entry record(s)", updated))
+	}
+	if added > 0 {
+		done = append(done, fmt.Sprintf("recorded %d entry record(s)", added))
+	}
+	if err := s.Save(); err != nil {
+		return "", fmt.Errorf("failed to save state: %w", err)
+	}
+	return strings.Join(done, ", "), nil
+}
+
+// diagnoseTarget checks a config file and the rules directories that go with it
+func diagnoseTarget(s *state.State, cwd string, t syncTarget) []diagnosis {
+	var diagnoses []diagnosis
+	providers := strings.Join(t.Providers, ", ")
+
+	// A provider that has not been synced yet has no files to check
+	configPath := filepath.Join(cwd, t.ConfigFile)
+	_, err := os.Stat(configPath)
+	synced := err == nil || slices.Contains(t.Providers, s.CurrentProvider)
+
+	if !insideRepo(cwd, t.ConfigFile) {
+		diagnoses = append(diagnoses, diagnosis{Where: t.ConfigFile, Problem: fmt.Sprintf("config file of %s is outside the repository; point it inside with 'aipad providers add'", providers)})
+	} else if err == nil {
+		diagnoses = append(diagnoses, diagnoseConfigFile(t.ConfigFile, configPath)...)
+	} else if synced {
+		diagnoses = append(diagnoses, diagnosis{Where: t.ConfigFile, Problem: "missing; run 'aipad sync'"})
+	}
+
+	for _, rulesDir := range t.RulesDirs {
+		if !insideRepo(cwd, rulesDir) {
+			diagnoses = append(diagnoses, diagnosis{Where: rulesDir, Problem: fmt.Sprintf("rules directory of %s is outside the repository; point it inside with 'aipad providers add'", providers)})
+			continue
+		}
+		if synced {
+			diagnoses = append(diagnoses, diagnoseRulesDir(s, cwd, t, rulesDir)...)
+		}
+	}
+	return diagnoses
+}
+
+// diagnoseConfigFile checks that a config file is writable and that its markers form blocks
+func diagnoseConfigFile(name, path string) []diagnosis {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return []diagnosis{{Where: name, Problem: fmt.Sprintf("not writable (%v)", err)}}
+	}
+	f.Close()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return []diagnosis{{Where: name, Problem: fmt.Sprintf("cannot be read (%v)", err)}}
+	}
+
+	fix := once(func() (string, error) {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		repaired, n := syncpkg.RepairMarkers(string(content))
+		if n == 0 {
+			return "", nil
+		}
+		if err := os.WriteFile(path, []byte(repaired), 0644); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("removed %d stray marker(s) or duplicate block(s); the next sync adds any missing blocks", n), nil
+	})
+	var diagnoses []diagnosis
+	for _, p := range syncpkg.CheckMarkers(string(content)) {
+		d := diagnosis{Where: fmt.Sprintf("%s:%d", name, p.Line), Problem: p.Problem}
+		if p.Fixable {
+			d.Fix = fix
+		}
+		diagnoses = append(diagnoses, d)
+	}
+	return diagnoses
+}
+
+// diagnoseRulesDir checks that a rules directory exists, is writable and has a current scratchpad
+func diagnoseRulesDir(s *state.State, cwd string, t syncTarget, rulesDir string) []diagnosis {
+	scratchpadPath := filepath.Join(cwd, state.AIPadDir, state.ScratchpadFile)
+	relink := once(func() (string, error) {
+		if err := foldDrift(s, t.ConfigFile, []string{rulesDir}, false); err != nil {
+			return "", err
+		}
+		if err := syncpkg.EnsureRulesDir(rulesDir); err != nil {
+			return "", err
+		}
+		mode, err := linkRules(t.Provider, rulesDir, scratchpadPath)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("put the scratchpad back (%s)", mode), nil
+	})
+
+	dir := filepath.Join(cwd, rulesDir)
+	info, err := os.Stat(dir)
+	switch {
+	case os.IsNotExist(err):
+		return []diagnosis{{Where: rulesDir, Problem: "missing", Fix: relink}}
+	case err != nil:
+		return []diagnosis{{Where: rulesDir, Problem: fmt.Sprintf("cannot be read (%v)", err)}}
+	case !info.IsDir():
+		return []diagnosis{{Where: rulesDir, Problem: "not a directory; move the file out of the way"}}
+	}
+	probe, err := os.CreateTemp(dir, ".aipad-doctor-*")
+	if err != nil {
+		return []diagnosis{{Where: rulesDir, Problem: fmt.Sprintf("not writable (%v)", err)}}
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+
+	link, err := syncpkg.InspectRulesLink(scratchpadPath, rulesDir)
+	switch {
+	case err != nil:
+		return []diagnosis{{Where: link.Path, Problem: fmt.Sprintf("cannot be inspected (%v)", err)}}
+	case link.Mode == "":
+		return []diagnosis{{Where: link.Path, Problem: "missing", Fix: relink}}
+	case link.Mode == config.LinkSymlink && link.Stale:
+		return []diagnosis{{Where: link.Path, Problem: fmt.Sprintf("symlink to %s, which is not the scratchpad", link.Target), Fix: relink}}
+	case link.Stale:
+		return []diagnosis{{Where: link.Path, Problem: "out of date", Fix: relink}}
+	}
+	return nil
+}
+
+// insideRepo reports whether path, relative to the project root cwd, stays
+// inside the repository, also once the symlinks along it are resolved
+func insideRepo(cwd, path string) bool {
+	if !filepath.IsLocal(filepath.Clean(path)) {
+		return false
+	}
+	root, err := filepath.EvalSymlinks(cwd)
+	if err != nil {
+		return true
+	}
+	// Resolve the longest part of the path that exists
+	for p := filepath.Join(cwd, path); ; p = filepath.Dir(p) {
+		if resolved, err := filepath.EvalSymlinks(p); err == nil {
+			rel, err := filepath.Rel(root, resolved)
+			return err == nil && filepath.IsLocal(rel)
+		}
+		if p == cwd || p == filepath.Dir(p) {
+			return true
+		}
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+	doctorCmd.Flags().BoolVar(&doctorFix, "fix", false, "apply the repairs that cannot lose text")
+}
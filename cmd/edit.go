@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"aipad/internal/crypto"
+	"aipad/internal/scratchpad"
+	"aipad/internal/state"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// editCmd represents the edit command
+var editCmd = &cobra.Command{
+	Use:   "edit <id> [\"<text>\"]",
+	Short: "Edit a context entry",
+	Long: `Replace the text of an existing context entry.
+
+If no text is given, the entry is opened in $EDITOR.
+
+This command will:
+- Rewrite the entry in the scratchpad, keeping its ID and timestamp
+- Update the entry's hash and history in state.json
+- Re-sync the current provider's rules copy and config file
+
+Example:
+  aipad edit 3f2a9c1d "Switched the API from REST to gRPC"
+  aipad edit 3f2a`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) < 1 || len(args) > 2 {
+			return fmt.Errorf("requires an entry ID and optionally the new text")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		// 1. Load existing state
+		s, err := state.Load()
+		if err != nil {
+			fmt.Printf("Error: No active session found. Run 'aipad new <provider>' first.\n")
+			os.Exit(1)
+		}
+		defer commitJournal(beginJournal("edit", args, append(sessionPaths(), providerPaths(s, s.CurrentProvider)...)...))
+
+		i, err := s.FindEntry(args[0])
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(1)
+		}
+		id := s.Entries[i].ID
+
+		// 2. Locate the entry in the scratchpad
+		scratchpadPath, err := state.GetScratchpadPath()
+		if err != nil {
+			fmt.Printf("Error getting current directory: %v\n", err)
+			exit(1)
+		}
+		content, err := os.ReadFile(scratchpadPath)
+		if err != nil {
+			fmt.Printf("Error reading scratchpad: %v\n", err)
+			exit(1)
+		}
+		entry, ok := scratchpad.Find(scratchpad.Parse(string(content)), id)
+		if !ok {
+			fmt.Printf("Error: entry '%s' not found in scratchpad\n", id)
+			exit(1)
+		}
+
+		// 3. Get the new text
+		var text string
+		if len(args) == 2 {
+			text = strings.TrimSpace(args[1])
+		} else {
+			text, err = editText(entry.Content)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				exit(1)
+			}
+		}
+		if text == "" {
+			fmt.Println("Error: entry text cannot be empty. Use 'aipad rm' to remove an entry.")
+			exit(1)
+		}
+		if text == entry.Content {
+			fmt.Println("No changes made.")
+			return
+		}
+
+		hash := crypto.GenerateHash(text)
+		for j, h := range s.ContextHashes {
+			if j != i && h == hash {
+				fmt.Printf("Error: another entry (%s) already has this content.\n", s.Entries[j].ID)
+				exit(1)
+			}
+		}
+
+		// 4. Rewrite the scratchpad
+		entry.Content = text
+		updated, err := scratchpad.Replace(string(content), entry)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(1)
+		}
+		if err := os.WriteFile(scratchpadPath, []byte(updated), 0644); err != nil {
+			fmt.Printf("Error writing to scratchpad: %v\n", err)
+			exit(1)
+		}
+
+		// 5. Update state records
+		s.UpdateEntry(i, text, hash)
+		if err := s.Save(); err != nil {
+			fmt.Printf("Error saving state: %v\n", err)
+			exit(1)
+		}
+		fmt.Printf("Updated entry %s.\n", id)
+
+		// 6. Re-sync the current provider
+		if err := syncProvider(s, s.CurrentProvider); err != nil {
+			fmt.Printf("Warning: Could not sync %s: %v\n", s.CurrentProvider, err)
+			return
+		}
+		fmt.Printf("Synced %s.\n", s.CurrentProvider)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(editCmd)
+}
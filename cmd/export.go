@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"aipad/internal/scratchpad"
 	"aipad/internal/state"
 	"fmt"
 	"os"
@@ -23,10 +24,14 @@ This command will:
 
 Supported formats: .md (markdown), .txt (text), .json
 
+Use --tag and --exclude-tag, or --provider, --author and --source, to
+export only a subset of entries. Entry headers include attribution.
+
 Example:
   aipad export
   aipad export conversation.md
-  aipad export conversation.json`,
+  aipad export conversation.json
+  aipad export --tag auth auth-notes.md`,
 	Args: func(cmd *cobra.Command, args []string) error {
 		if len(args) > 1 {
 			return fmt.Errorf("accepts at most one argument: [filename]")
@@ -34,6 +39,12 @@ Example:
 		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
+		filter, err := filterFromFlags(cmd)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
 		// 1. Load existing state
 		s, err := state.Load()
 		if err != nil {
@@ -61,6 +72,17 @@ Example:
 			os.Exit(1)
 		}
 
+		// Normalize the scratchpad and apply tag filters
+		doc := scratchpad.ParseDocument(string(content))
+		entryCount := len(doc.Entries)
+		if filter.IsEmpty() {
+			content = []byte(doc.String())
+		} else {
+			entries := filter.Select(doc.Entries)
+			content = []byte(scratchpad.FormatAll(entries))
+			entryCount = len(entries)
+		}
+
 		// 4. Determine file extension and format
 		ext := filepath.Ext(outputFile)
 		var exportContent string
@@ -78,7 +100,7 @@ Example:
 				s.CurrentProvider,
 				s.CreatedAt.Format(time.RFC3339),
 				time.Now().Format(time.RFC3339),
-				len(s.ContextHashes))
+				entryCount)
 		case ".txt":
 			exportContent = fmt.Sprintf("AIPad Conversation Export\n"+
 				"==========================\n"+
@@ -92,7 +114,7 @@ Example:
 				s.CurrentProvider,
 				s.CreatedAt.Format("2006-01-02 15:04:05"),
 				time.Now().Format("2006-01-02 15:04:05"),
-				len(s.ContextHashes),
+				entryCount,
 				string(content))
 		default: // .md or any other format
 			exportContent = fmt.Sprintf("# AIPad Conversation Export\n\n"+
@@ -107,7 +129,7 @@ Example:
 				s.CurrentProvider,
 				s.CreatedAt.Format("2006-01-02 15:04:05"),
 				time.Now().Format("2006-01-02 15:04:05"),
-				len(s.ContextHashes),
+				entryCount,
 				string(content))
 		}
 
@@ -120,11 +142,13 @@ Example:
 
 		fmt.Printf("Exported conversation history to: %s\n", outputFile)
 		fmt.Printf("  Session: %s\n", s.SessionID)
-		fmt.Printf("  Entries: %d\n", len(s.ContextHashes))
+		fmt.Printf("  Entries: %d\n", entryCount)
 		fmt.Printf("  Format: %s\n", ext)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(exportCmd)
+	addTagFilterFlags(exportCmd)
+	addAttributionFilterFlags(exportCmd)
 }
@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"aipad/internal/crypto"
+	"aipad/internal/scratchpad"
+	"aipad/internal/state"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var fmtCheck bool
+
+// fmtCmd represents the fmt command
+var fmtCmd = &cobra.Command{
+	Use:   "fmt",
+	Short: "Normalize the scratchpad into canonical form",
+	Long: `Rewrite a hand-edited scratchpad into canonical form without losing content.
+
+This command will:
+- Give every entry a canonical header and an explicit end marker
+- Keep text outside of entries in place
+- Bring state.json in line with the scratchpad: edited entries get new
+  hashes, hand-written entries are recorded so dedup and IDs cover them
+- Re-sync the current provider
+
+Use --check to report whether the scratchpad is already canonical
+without writing anything.
+
+Example:
+  aipad fmt
+  aipad fmt --check`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// 1. Load existing state
+		s, err := state.Load()
+		if err != nil {
+			fmt.Printf("Error: No active session found. Run 'aipad new <provider>' first.\n")
+			os.Exit(1)
+		}
+		if !fmtCheck {
+			defer commitJournal(beginJournal("fmt", args, append(sessionPaths(), providerPaths(s, s.CurrentProvider)...)...))
+		}
+
+		// 2. Parse the scratchpad
+		scratchpadPath, err := state.GetScratchpadPath()
+		if err != nil {
+			fmt.Printf("Error getting current directory: %v\n", err)
+			exit(1)
+		}
+		content, err := os.ReadFile(scratchpadPath)
+		if err != nil {
+			fmt.Printf("Error reading scratchpad: %v\n", err)
+			exit(1)
+		}
+		doc := scratchpad.ParseDocument(string(content))
+		canonical := doc.String()
+
+		if fmtCheck {
+			if canonical != string(content) {
+				fmt.Println("Scratchpad is not in canonical form. Run 'aipad fmt' to fix it.")
+				exit(1)
+			}
+			fmt.Println("Scratchpad is in canonical form.")
+			return
+		}
+
+		// 3. Write the canonical form
+		if canonical != string(content) {
+			if err := os.WriteFile(scratchpadPath, []byte(canonical), 0644); err != nil {
+				fmt.Printf("Error writing to scratchpad: %v\n", err)
+				exit(1)
+			}
+			fmt.Printf("Formatted scratchpad (%d entries).\n", len(doc.Entries))
+		} else {
+			fmt.Println("Scratchpad is already in canonical form.")
+		}
+
+		// 4. Reconcile state records with the scratchpad
+		updated, added := reconcileState(s, doc.Entries)
+		if updated > 0 || added > 0 {
+			if err := s.Save(); err != nil {
+				fmt.Printf("Error saving state: %v\n", err)
+				exit(1)
+			}
+			fmt.Printf("Updated %d and recorded %d entries in state.json.\n", updated, added)
+		}
+
+		var seen []string
+		for _, e := range doc.Entries {
+			if slices.Contains(seen, e.ID) {
+				fmt.Printf("Warning: ID %s is used by more than one entry.\n", e.ID)
+			}
+			seen = append(seen, e.ID)
+		}
+		for _, e := range s.Entries {
+			if !slices.Contains(seen, e.ID) {
+				fmt.Printf("Warning: entry %s is in state.json but not in the scratchpad. Run 'aipad rm %s' to drop it.\n", e.ID, e.ID)
+			}
+		}
+
+		// 5. Re-sync the current provider
+		if canonical != string(content) {
+			if err := syncProvider(s, s.CurrentProvider); err != nil {
+				fmt.Printf("Warning: Could not sync %s: %v\n", s.CurrentProvider, err)
+				return
+			}
+			fmt.Printf("Synced %s.\n", s.CurrentProvider)
+		}
+	},
+}
+
+// reconcileState updates state records to match the parsed scratchpad entries.
+// It returns the number of records updated and added.
+func reconcileState(s *state.State, entries []scratchpad.Entry) (updated, added int) {
+	for _, e := range entries {
+		hash := crypto.GenerateHash(e.Content)
+		i := s.EntryIndex(e.ID)
+		if i < 0 {
+			meta := s.AddEntry(e.Content, hash)
+			meta.ID = e.ID
+			if ts, err := time.ParseInLocation(scratchpad.TimestampFormat, e.Timestamp, time.Local); err == nil {
+				meta.CreatedAt = ts
+			}
+			applyEntryMeta(meta, e)
+			added++
+			continue
+		}
+
+		metaChanged := applyEntryMeta(&s.Entries[i], e)
+		if s.ContextHashes[i] != hash || metaChanged {
+			s.UpdateEntry(i, e.Content, hash)
+			updated++
+		}
+	}
+	return updated, added
+}
+
+// applyEntryMeta copies the header metadata of e onto meta and reports whether anything changed
+func applyEntryMeta(meta *state.Entry, e scratchpad.Entry) bool {
+	kind := meta.Kind
+	if kind == "" {
+		kind = scratchpad.KindNote
+	}
+	var expires time.Time
+	if meta.ExpiresAt != nil {
+		expires = *meta.ExpiresAt
+	}
+
+	changed := kind != e.Kind || meta.Pinned != e.Pinned || !slices.Equal(meta.Tags, e.Tags) ||
+		meta.Provider != e.Provider || meta.Author != e.Author || meta.Agent != e.Agent ||
+		!expires.Equal(e.Expires) || !slices.Equal(meta.Supersedes, e.Supersedes) || meta.SupersededBy != e.SupersededBy
+
+	meta.Kind = e.Kind
+	meta.Pinned = e.Pinned
+	meta.Tags = e.Tags
+	meta.Provider = e.Provider
+	meta.Author = e.Author
+	meta.Agent = e.Agent
+	meta.Supersedes = e.Supersedes
+	meta.SupersededBy = e.SupersededBy
+	meta.ExpiresAt = nil
+	if !e.Expires.IsZero() {
+		t := e.Expires
+		meta.ExpiresAt = &t
+	}
+	return changed
+}
+
+func init() {
+	rootCmd.AddCommand(fmtCmd)
+	fmtCmd.Flags().BoolVar(&fmtCheck, "check", false, "report whether the scratchpad is canonical without writing")
+}
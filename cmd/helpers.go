@@ -0,0 +1,426 @@
+package cmd
+
+import (
+	"aipad/internal/author"
+	"aipad/internal/budget"
+	"aipad/internal/config"
+	"aipad/internal/crypto"
+	"aipad/internal/diff"
+	"aipad/internal/journal"
+	"aipad/internal/scratchpad"
+	"aipad/internal/state"
+	syncpkg "aipad/internal/sync"
+	"aipad/internal/todo"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// syncProvider copies the scratchpad into the provider's rules directory
+// and refreshes the managed block in its config file
+func syncProvider(s *state.State, provider string) error {
+	providerConfig, ok := s.Providers[provider]
+	if !ok {
+		return fmt.Errorf("provider configuration not found for '%s'", provider)
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	scratchpadPath := filepath.Join(cwd, state.AIPadDir, state.ScratchpadFile)
+
+	if err := foldDrift(s, providerConfig.ConfigFile, []string{providerConfig.RulesDir}, false); err != nil {
+		return err
+	}
+	if err := syncpkg.EnsureRulesDir(providerConfig.RulesDir); err != nil {
+		return fmt.Errorf("failed to create rules directory: %w", err)
+	}
+	if _, err := linkRules(provider, providerConfig.RulesDir, scratchpadPath); err != nil {
+		return fmt.Errorf("failed to link scratchpad: %w", err)
+	}
+
+	opts, err := blockOptions(s, provider)
+	if err != nil {
+		return err
+	}
+	if opts.Budget, err = providerBudget(provider); err != nil {
+		return err
+	}
+	configPath := filepath.Join(cwd, providerConfig.ConfigFile)
+	if _, err := syncpkg.SyncProviderConfig(configPath, scratchpadPath, opts); err != nil {
+		return fmt.Errorf("failed to update config file: %w", err)
+	}
+	return nil
+}
+
+// linkRules puts the scratchpad into a provider's rules directory using the
+// provider's link mode and returns the mode used. Falling back to a copy
+// because the filesystem rejected the link only warns.
+func linkRules(provider, rulesDir, scratchpadPath string) (string, error) {
+	settings, err := config.LoadSettings()
+	if err != nil {
+		return "", err
+	}
+	mode := settings.LinkFor(provider)
+	used, err := syncpkg.LinkScratchpadToRules(scratchpadPath, rulesDir, mode)
+	if err != nil {
+		return "", err
+	}
+	if used != mode {
+		fmt.Printf("Warning: Could not %s the scratchpad into %s; copied it instead.\n", mode, rulesDir)
+	}
+	return used, nil
+}
+
+// describeLink says how the scratchpad got into a rules directory, e.g. "Symlinked scratchpad into .claude/rules/"
+func describeLink(mode, rulesDir string) string {
+	switch mode {
+	case config.LinkSymlink:
+		return "Symlinked scratchpad into " + rulesDir
+	case config.LinkHardlink:
+		return "Hard-linked scratchpad into " + rulesDir
+	}
+	return "Copied scratchpad to " + rulesDir
+}
+
+// foldDrift looks for edits made to a config file's managed blocks or to a
+// rules directory copy since aipad last wrote them, and folds them back into
+// the scratchpad so the next sync does not destroy them. With strict, any
+// such edit is an error instead. Edits that conflict with the scratchpad are
+// always an error; nothing is changed then.
+func foldDrift(s *state.State, configFile string, rulesDirs []string, strict bool) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	scratchpadPath := filepath.Join(cwd, state.AIPadDir, state.ScratchpadFile)
+
+	// 1. Find the files edited since the last sync. Rules copies come first:
+	// they are merged line by line, before folding a block appends entries.
+	ledger := syncpkg.LoadLedger()
+	var drifts []*syncpkg.Drift
+	for _, rulesDir := range rulesDirs {
+		d, err := syncpkg.RulesDrift(ledger, scratchpadPath, rulesDir)
+		if err != nil {
+			return err
+		}
+		if d != nil {
+			drifts = append(drifts, d)
+		}
+	}
+	blocks, err := syncpkg.BlockDrift(ledger, filepath.Join(cwd, configFile))
+	if err != nil {
+		return err
+	}
+	drifts = append(drifts, blocks...)
+	if len(drifts) == 0 {
+		return nil
+	}
+	if strict {
+		var paths []string
+		for _, d := range drifts {
+			paths = append(paths, d.String())
+		}
+		return fmt.Errorf("%s edited since the last sync; run without --strict to merge the edits into the scratchpad, or with --force to discard them", strings.Join(paths, ", "))
+	}
+
+	// 2. Merge the edits into the scratchpad
+	content, err := os.ReadFile(scratchpadPath)
+	if err != nil {
+		return fmt.Errorf("failed to read scratchpad: %w", err)
+	}
+	original := string(content)
+	updated := original
+	var done, report []string
+	for _, d := range drifts {
+		var changes []string
+		if d.Rules {
+			merged, ok := diff.Merge3(d.Base, updated, d.Current)
+			if !ok {
+				return fmt.Errorf("%s: edits conflict with the scratchpad; copy them into %s by hand, then sync with --force", d, filepath.Join(state.AIPadDir, state.ScratchpadFile))
+			}
+			updated = merged
+			changes = append(changes, "merged the copy's edits")
+		} else {
+			fold, err := syncpkg.FoldBlock(d.Base, d.Current, updated)
+			if err != nil {
+				return fmt.Errorf("%s: %w; copy the edits into %s by hand, then sync with --force", d, err, filepath.Join(state.AIPadDir, state.ScratchpadFile))
+			}
+			updated = fold.Scratchpad
+			for _, id := range fold.Edited {
+				changes = append(changes, "updated entry "+id)
+			}
+			if fold.Added != "" {
+				changes = append(changes, "added entry "+fold.Added)
+			}
+			for _, id := range fold.Done {
+				changes = append(changes, "closed task "+id)
+			}
+			done = append(done, fold.Done...)
+			if len(fold.Removed) > 0 {
+				report = append(report, fmt.Sprintf("Note: %s removed from %s; they stay in the scratchpad ('aipad rm' deletes them).", strings.Join(fold.Removed, ", "), d))
+			}
+		}
+		if len(changes) > 0 {
+			report = append(report, fmt.Sprintf("Folded edits from %s into the scratchpad: %s.", d, strings.Join(changes, ", ")))
+		}
+	}
+
+	// 3. Persist the merged scratchpad, state and tasks
+	if updated != original {
+		if err := os.WriteFile(scratchpadPath, []byte(updated), 0644); err != nil {
+			return fmt.Errorf("failed to write scratchpad: %w", err)
+		}
+		reconcileState(s, scratchpad.Parse(updated))
+		if err := s.Save(); err != nil {
+			return fmt.Errorf("failed to save state: %w", err)
+		}
+	}
+	if len(done) > 0 {
+		list, err := todo.Load()
+		if err != nil {
+			return err
+		}
+		by := author.Detect(s.CurrentProvider, "").String()
+		for _, id := range done {
+			if i, err := list.Find(id); err == nil && list.Items[i].Status != todo.StatusDone {
+				list.Done(i, by)
+			}
+		}
+		if err := list.Save(); err != nil {
+			return err
+		}
+	}
+	for _, line := range report {
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// providerBudget returns the managed block budget configured for provider
+func providerBudget(provider string) (budget.Budget, error) {
+	settings, err := config.LoadSettings()
+	if err != nil {
+		return budget.Budget{}, err
+	}
+	return settings.BudgetFor(provider), nil
+}
+
+// blockOptions returns the options that render provider's managed blocks:
+// the session for its instructions and the open tasks
+func blockOptions(s *state.State, provider string) (syncpkg.Options, error) {
+	todos, err := todo.Load()
+	if err != nil {
+		return syncpkg.Options{}, fmt.Errorf("failed to load todos: %w", err)
+	}
+	return syncpkg.Options{Provider: provider, Session: s, Todos: todos.Open()}, nil
+}
+
+// reportOmitted tells how many entries the budget left out of a provider's managed block
+func reportOmitted(provider string, block syncpkg.Block, b budget.Budget) {
+	if len(block.Omitted) > 0 {
+		fmt.Printf("Left %d entries out of %s's managed block to fit its budget of %s.\n", len(block.Omitted), provider, b)
+	}
+}
+
+// sessionPaths returns the paths of state.json, scratchpad.md and todos.json
+func sessionPaths() []string {
+	statePath, _ := state.GetStatePath()
+	scratchpadPath, _ := state.GetScratchpadPath()
+	todoPath, _ := todo.GetTodoPath()
+	return []string{statePath, scratchpadPath, todoPath}
+}
+
+// providerPaths returns the files a sync writes for a provider: the rules
+// copy, the config file and the ledger of what was written to them. Undoing a
+// sync must restore the ledger too, or edits it brings back go undetected.
+func providerPaths(s *state.State, provider string) []string {
+	providerConfig, ok := s.Providers[provider]
+	if !ok {
+		return nil
+	}
+	ledgerPath, _ := syncpkg.GetLedgerPath()
+	return []string{
+		filepath.Join(providerConfig.RulesDir, syncpkg.RulesScratchpadFile),
+		providerConfig.ConfigFile,
+		ledgerPath,
+	}
+}
+
+// openJournal is the operation begun by the running command and not yet committed
+var openJournal *journal.Operation
+
+// beginJournal snapshots the files a mutating command is about to change so
+// 'aipad undo' can restore them. Journal failures only warn.
+func beginJournal(command string, args []string, paths ...string) *journal.Operation {
+	op, err := journal.Begin(command, args, paths...)
+	if err != nil {
+		fmt.Printf("Warning: Could not journal %s: %v\n", command, err)
+		return nil
+	}
+	openJournal = op
+	return op
+}
+
+// commitJournal records a journaled command once it has finished
+func commitJournal(op *journal.Operation) {
+	if op == nil {
+		return
+	}
+	if op == openJournal {
+		openJournal = nil
+	}
+	if err := op.Commit(); err != nil {
+		fmt.Printf("Warning: Could not journal %s: %v\n", op.Command, err)
+	}
+}
+
+// exit ends a command that has begun its journal. os.Exit skips deferred
+// calls, so the journal is committed first: a command that fails halfway
+// can still be undone.
+func exit(code int) {
+	commitJournal(openJournal)
+	os.Exit(code)
+}
+
+// similarityMatcher builds the near-duplicate matcher configured for the
+// project in .aipad/config.json, comparing against history. Callers attach
+// an Index when the matcher is Indexable.
+func similarityMatcher(settings *config.Settings, history []string) (*crypto.Matcher, error) {
+	m, err := crypto.NewMatcher(settings.Similarity, settings.Normalize, history)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", config.SettingsFile, err)
+	}
+	return m, nil
+}
+
+// similarityIndex loads the persisted similarity index and brings it up to date
+// with history. The index is only a cache, so failures to persist it only warn.
+func similarityIndex(history []string) *crypto.Index {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return crypto.NewIndex()
+	}
+	path := filepath.Join(cwd, state.AIPadDir, crypto.IndexFile)
+
+	ix, err := crypto.LoadIndex(path)
+	if err != nil {
+		fmt.Printf("Warning: Could not read similarity index: %v\n", err)
+		ix = crypto.NewIndex()
+	}
+	if ix.Update(history) {
+		if err := ix.Save(path); err != nil {
+			fmt.Printf("Warning: Could not save similarity index: %v\n", err)
+		}
+	}
+	return ix
+}
+
+// dedupLimits returns the similarity threshold and the minimum length for the
+// fuzzy duplicate check: a --threshold flag wins over .aipad/config.json,
+// which wins over the defaults
+func dedupLimits(cmd *cobra.Command, settings *config.Settings, flagThreshold float64) (float64, int, error) {
+	threshold := crypto.SimilarityThreshold
+	if settings.Threshold != 0 {
+		threshold = settings.Threshold
+	}
+	if cmd.Flags().Changed("threshold") {
+		if err := config.ValidateThreshold(flagThreshold); err != nil {
+			return 0, 0, err
+		}
+		threshold = flagThreshold
+	}
+
+	minLength := crypto.MinSimilarityLength
+	if settings.MinLength != nil {
+		minLength = *settings.MinLength
+	}
+	return threshold, minLength, nil
+}
+
+// stdinIsTerminal reports whether aipad can prompt on stdin
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// editText opens $EDITOR on a temporary file seeded with initial and returns the saved text
+func editText(initial string) (string, error) {
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vi"
+	}
+
+	f, err := os.CreateTemp("", "aipad-*.md")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.WriteString(initial); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+
+	// EDITOR may carry arguments, e.g. "code --wait"
+	parts := strings.Fields(editor)
+	c := exec.Command(parts[0], append(parts[1:], f.Name())...)
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	if err := c.Run(); err != nil {
+		return "", fmt.Errorf("editor '%s' failed: %w", editor, err)
+	}
+
+	edited, err := os.ReadFile(f.Name())
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(edited)), nil
+}
+
+// addTagFilterFlags registers the --tag and --exclude-tag flags on cmd
+func addTagFilterFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSlice("tag", nil, "only include entries with this tag (repeatable)")
+	cmd.Flags().StringSlice("exclude-tag", nil, "skip entries with this tag (repeatable)")
+}
+
+// addAttributionFilterFlags registers the --provider, --author and --source flags on cmd
+func addAttributionFilterFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSlice("provider", nil, "only include entries written while this provider was active (repeatable)")
+	cmd.Flags().StringSlice("author", nil, "only include entries written by this user (repeatable)")
+	cmd.Flags().String("source", "", "only include entries written by an 'agent' or a 'human'")
+}
+
+// filterFromFlags builds an entry filter from whichever filter flags cmd defines
+func filterFromFlags(cmd *cobra.Command) (scratchpad.Filter, error) {
+	tags, _ := cmd.Flags().GetStringSlice("tag")
+	excludeTags, _ := cmd.Flags().GetStringSlice("exclude-tag")
+
+	var filter scratchpad.Filter
+	var err error
+	if filter.Tags, err = scratchpad.NormalizeTags(tags); err != nil {
+		return filter, err
+	}
+	if filter.ExcludeTags, err = scratchpad.NormalizeTags(excludeTags); err != nil {
+		return filter, err
+	}
+
+	if cmd.Flags().Lookup("source") != nil {
+		filter.Providers, _ = cmd.Flags().GetStringSlice("provider")
+		filter.Authors, _ = cmd.Flags().GetStringSlice("author")
+		filter.Source, _ = cmd.Flags().GetString("source")
+		if filter.Source != "" && filter.Source != author.SourceAgent && filter.Source != author.SourceHuman {
+			return filter, fmt.Errorf("invalid source '%s': must be '%s' or '%s'", filter.Source, author.SourceAgent, author.SourceHuman)
+		}
+	}
+	return filter, nil
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"aipad/internal/journal"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var historyLimit int
+
+// historyCmd represents the history command
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List recent operations",
+	Long: `List the operations recorded in the journal, newest first.
+The first one listed is what 'aipad undo' rolls back.
+
+Example:
+  aipad history
+  aipad history -n 5`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ops, err := journal.List()
+		if err != nil {
+			fmt.Printf("Error: Failed to read journal: %v\n", err)
+			os.Exit(1)
+		}
+		if len(ops) == 0 {
+			fmt.Println("No operations recorded yet.")
+			return
+		}
+
+		if historyLimit > 0 && len(ops) > historyLimit {
+			ops = ops[:historyLimit]
+		}
+		for _, op := range ops {
+			fmt.Printf("[%s] %s (%d file(s))\n", op.Time.Format("2006-01-02 15:04:05"), describeOperation(op), len(op.Files))
+		}
+	},
+}
+
+// describeOperation renders an operation as the command line that ran it
+func describeOperation(op *journal.Operation) string {
+	line := "aipad " + op.Command
+	for _, arg := range op.Args {
+		if strings.ContainsAny(arg, " \t\n") {
+			arg = fmt.Sprintf("%q", truncate(arg, 40))
+		}
+		line += " " + arg
+	}
+	return line
+}
+
+func init() {
+	historyCmd.Flags().IntVarP(&historyLimit, "limit", "n", 10, "Number of operations to show (0 for all)")
+	rootCmd.AddCommand(historyCmd)
+}
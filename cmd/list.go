@@ -1,12 +1,13 @@
 package cmd
 
 import (
+	"aipad/internal/scratchpad"
 	"aipad/internal/state"
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 )
@@ -16,10 +17,21 @@ var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "Show conversation history",
 	Long: `Display the conversation history from the scratchpad.
-Shows all context entries with their timestamps.`,
+Shows all context entries with their timestamps.
+
+Example:
+  aipad list
+  aipad list --tag auth --exclude-tag debug
+  aipad list --provider claude --source agent`,
 	Run: func(cmd *cobra.Command, args []string) {
+		filter, err := filterFromFlags(cmd)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
 		// Check if session exists
-		_, err := state.Load()
+		_, err = state.Load()
 		if err != nil {
 			fmt.Println("No active session found. Run 'aipad new <provider>' to start.")
 			os.Exit(1)
@@ -50,9 +62,36 @@ Shows all context entries with their timestamps.`,
 		fmt.Println()
 
 		// Parse entries
-		entries := parseEntries(string(content))
+		entries := filter.Select(scratchpad.Parse(string(content)))
+		if len(entries) == 0 {
+			fmt.Println("  No entries match the given filters.")
+			return
+		}
+		now := time.Now()
 		for i, entry := range entries {
-			fmt.Printf("  [%d] %s\n", i+1, entry.Timestamp)
+			flags := ""
+			if entry.Pinned {
+				flags += "  [pinned]"
+			}
+			if entry.Expired(now) {
+				flags += "  [expired]"
+			}
+			if entry.SupersededBy != "" {
+				flags += "  [superseded by " + entry.SupersededBy + "]"
+			}
+			fmt.Printf("  [%d] %s  (id: %s)  %s%s\n", i+1, entry.Timestamp, entry.ID, entry.Kind, flags)
+			if len(entry.Tags) > 0 {
+				fmt.Printf("      tags: %s\n", strings.Join(entry.Tags, ", "))
+			}
+			if by := attribution(entry); by != "" {
+				fmt.Printf("      by:   %s\n", by)
+			}
+			if len(entry.Supersedes) > 0 {
+				fmt.Printf("      supersedes: %s\n", strings.Join(entry.Supersedes, ", "))
+			}
+			if !entry.Expires.IsZero() && !entry.Expired(now) {
+				fmt.Printf("      expires: %s\n", entry.Expires.Format(scratchpad.TimestampFormat))
+			}
 			// Truncate content to 80 chars
 			preview := strings.ReplaceAll(entry.Content, "\n", " ")
 			if len(preview) > 80 {
@@ -63,28 +102,25 @@ Shows all context entries with their timestamps.`,
 	},
 }
 
-type Entry struct {
-	Timestamp string
-	Content   string
-}
-
-func parseEntries(content string) []Entry {
-	var entries []Entry
-	// Match pattern: ## [timestamp] Context Update
-	pattern := regexp.MustCompile(`## \[([^\]]+)\] Context Update\n([\s\S]*?)(?:---|$)`)
-	matches := pattern.FindAllStringSubmatch(content, -1)
-
-	for _, match := range matches {
-		if len(match) >= 3 {
-			entries = append(entries, Entry{
-				Timestamp: match[1],
-				Content:   strings.TrimSpace(match[2]),
-			})
-		}
+// attribution describes who wrote an entry, e.g. "alice via claude (agent: claude-code)"
+func attribution(e scratchpad.Entry) string {
+	var parts []string
+	if e.Author != "" {
+		parts = append(parts, e.Author)
+	}
+	if e.Provider != "" {
+		parts = append(parts, "via "+e.Provider)
+	}
+	if e.Agent != "" {
+		parts = append(parts, "(agent: "+e.Agent+")")
+	} else if len(parts) > 0 {
+		parts = append(parts, "(human)")
 	}
-	return entries
+	return strings.Join(parts, " ")
 }
 
 func init() {
 	rootCmd.AddCommand(listCmd)
+	addTagFilterFlags(listCmd)
+	addAttributionFilterFlags(listCmd)
 }
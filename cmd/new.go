@@ -50,18 +50,22 @@ This command will:
 			os.Exit(1)
 		}
 
+		// Journal the files a new session overwrites so an accidental 'aipad new' can be undone
+		paths := append(sessionPaths(), providerPaths(state.NewState(provider), provider)...)
+		defer commitJournal(beginJournal("new", args, paths...))
+
 		// 2. Create/Update state.json
 		s := state.NewState(provider)
 		if err := s.Save(); err != nil {
 			fmt.Printf("Error saving state: %v\n", err)
-			os.Exit(1)
+			exit(1)
 		}
 		fmt.Println("Initialized .aipad/state.json")
 
 		// 3. Initialize scratchpad.md
 		if err := state.EnsureScratchpad(); err != nil {
 			fmt.Printf("Error creating scratchpad: %v\n", err)
-			os.Exit(1)
+			exit(1)
 		}
 		fmt.Println("Initialized .aipad/scratchpad.md")
 
@@ -73,7 +77,7 @@ This command will:
 		// Create file if it doesn't exist, populated with Agent Awareness instructions
 		if err := ensureConfigFileWithInstructions(configPath); err != nil {
 			fmt.Printf("Error ensuring provider config %s: %v\n", configPath, err)
-			os.Exit(1)
+			exit(1)
 		}
 		fmt.Printf("Ensured provider config exists: %s\n", configPath)
 
@@ -82,15 +86,28 @@ This command will:
 		cwd, err := os.Getwd()
 		if err != nil {
 			fmt.Printf("Error getting current directory: %v\n", err)
-			os.Exit(1)
+			exit(1)
 		}
 		scratchpadPath := filepath.Join(cwd, state.AIPadDir, state.ScratchpadFile)
 
-		if err := syncpkg.SyncProviderConfig(configPath, scratchpadPath); err != nil {
+		opts, err := blockOptions(s, provider)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(1)
+		}
+		b, err := providerBudget(provider)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(1)
+		}
+		opts.Budget = b
+		block, err := syncpkg.SyncProviderConfig(configPath, scratchpadPath, opts)
+		if err != nil {
 			fmt.Printf("Error syncing initial context to config: %v\n", err)
-			os.Exit(1)
+			exit(1)
 		}
 		fmt.Printf("Synced initial context to %s\n", configPath)
+		reportOmitted(provider, block, b)
 
 		fmt.Printf("Successfully started session! You are now using: %s\n", provider)
 	},
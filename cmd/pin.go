@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"aipad/internal/scratchpad"
+	"aipad/internal/state"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// pinCmd represents the pin command
+var pinCmd = &cobra.Command{
+	Use:   "pin <id>",
+	Short: "Pin a context entry",
+	Long: `Pin a context entry so it always appears first in every provider's
+config file and is never trimmed from the managed block, whatever the
+sync filters or budget. Expiry and superseding still apply: a pinned entry
+that has expired or been superseded is dropped like any other.
+
+Example:
+  aipad pin 3f2a9c1d`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("requires exactly one argument: <id>")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		setPinned(args[0], true)
+	},
+}
+
+// unpinCmd represents the unpin command
+var unpinCmd = &cobra.Command{
+	Use:   "unpin <id>",
+	Short: "Unpin a context entry",
+	Long: `Unpin a context entry so it is rendered in its regular section again.
+
+Example:
+  aipad unpin 3f2a9c1d`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("requires exactly one argument: <id>")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		setPinned(args[0], false)
+	},
+}
+
+// setPinned updates the pin state of an entry in state.json and the scratchpad,
+// then re-syncs the current provider
+func setPinned(idArg string, pinned bool) {
+	s, err := state.Load()
+	if err != nil {
+		fmt.Printf("Error: No active session found. Run 'aipad new <provider>' first.\n")
+		os.Exit(1)
+	}
+
+	command := "pin"
+	if !pinned {
+		command = "unpin"
+	}
+	defer commitJournal(beginJournal(command, []string{idArg}, append(sessionPaths(), providerPaths(s, s.CurrentProvider)...)...))
+
+	i, err := s.FindEntry(idArg)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		exit(1)
+	}
+	id := s.Entries[i].ID
+
+	action := "Pinned"
+	if !pinned {
+		action = "Unpinned"
+	}
+	if s.Entries[i].Pinned == pinned {
+		fmt.Printf("Entry %s is already %s.\n", id, strings.ToLower(action))
+		return
+	}
+
+	// Rewrite the entry header in the scratchpad
+	scratchpadPath, err := state.GetScratchpadPath()
+	if err != nil {
+		fmt.Printf("Error getting current directory: %v\n", err)
+		exit(1)
+	}
+	content, err := os.ReadFile(scratchpadPath)
+	if err != nil {
+		fmt.Printf("Error reading scratchpad: %v\n", err)
+		exit(1)
+	}
+	entry, ok := scratchpad.Find(scratchpad.Parse(string(content)), id)
+	if !ok {
+		fmt.Printf("Error: entry '%s' not found in scratchpad\n", id)
+		exit(1)
+	}
+	entry.Pinned = pinned
+	updated, err := scratchpad.Replace(string(content), entry)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		exit(1)
+	}
+	if err := os.WriteFile(scratchpadPath, []byte(updated), 0644); err != nil {
+		fmt.Printf("Error writing to scratchpad: %v\n", err)
+		exit(1)
+	}
+
+	// Persist the pin state
+	s.Entries[i].Pinned = pinned
+	if err := s.Save(); err != nil {
+		fmt.Printf("Error saving state: %v\n", err)
+		exit(1)
+	}
+	fmt.Printf("%s entry %s.\n", action, id)
+
+	if err := syncProvider(s, s.CurrentProvider); err != nil {
+		fmt.Printf("Warning: Could not sync %s: %v\n", s.CurrentProvider, err)
+		return
+	}
+	fmt.Printf("Synced %s.\n", s.CurrentProvider)
+}
+
+func init() {
+	rootCmd.AddCommand(pinCmd)
+	rootCmd.AddCommand(unpinCmd)
+}
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"aipad/internal/scratchpad"
+	"aipad/internal/state"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// rmCmd represents the rm command
+var rmCmd = &cobra.Command{
+	Use:   "rm <id>",
+	Short: "Remove a context entry",
+	Long: `Remove a context entry by its ID.
+
+This command will:
+- Delete the entry from the scratchpad
+- Drop its hash and history from state.json so it no longer blocks duplicates
+- Restore any entries it superseded
+- Re-sync the current provider's rules copy and config file
+
+Example:
+  aipad rm 3f2a9c1d`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("requires exactly one argument: <id>")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		// 1. Load existing state
+		s, err := state.Load()
+		if err != nil {
+			fmt.Printf("Error: No active session found. Run 'aipad new <provider>' first.\n")
+			os.Exit(1)
+		}
+		defer commitJournal(beginJournal("rm", args, append(sessionPaths(), providerPaths(s, s.CurrentProvider)...)...))
+
+		i, err := s.FindEntry(args[0])
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(1)
+		}
+		id := s.Entries[i].ID
+
+		// 2. Remove the entry from the scratchpad
+		scratchpadPath, err := state.GetScratchpadPath()
+		if err != nil {
+			fmt.Printf("Error getting current directory: %v\n", err)
+			exit(1)
+		}
+		content, err := os.ReadFile(scratchpadPath)
+		if err != nil {
+			fmt.Printf("Error reading scratchpad: %v\n", err)
+			exit(1)
+		}
+
+		updated, err := scratchpad.Remove(string(content), id)
+		if err != nil {
+			// The entry may have been deleted from the scratchpad by hand;
+			// still drop the state records so dedup stays consistent
+			fmt.Printf("Warning: %v\n", err)
+			updated = string(content)
+		}
+		// Entries this one superseded become current again
+		var restored []string
+		for j := range s.Entries {
+			if s.Entries[j].SupersededBy != id {
+				continue
+			}
+			s.Entries[j].SupersededBy = ""
+			restored = append(restored, s.Entries[j].ID)
+			if old, ok := scratchpad.Find(scratchpad.Parse(updated), s.Entries[j].ID); ok {
+				old.SupersededBy = ""
+				if updated, err = scratchpad.Replace(updated, old); err != nil {
+					fmt.Printf("Error: %v\n", err)
+					exit(1)
+				}
+			}
+		}
+
+		if err := os.WriteFile(scratchpadPath, []byte(updated), 0644); err != nil {
+			fmt.Printf("Error writing to scratchpad: %v\n", err)
+			exit(1)
+		}
+
+		// 3. Update state records
+		s.RemoveEntry(i)
+		if err := s.Save(); err != nil {
+			fmt.Printf("Error saving state: %v\n", err)
+			exit(1)
+		}
+		fmt.Printf("Removed entry %s.\n", id)
+		for _, restoredID := range restored {
+			fmt.Printf("Restored superseded entry %s.\n", restoredID)
+		}
+
+		// 4. Re-sync the current provider
+		if err := syncProvider(s, s.CurrentProvider); err != nil {
+			fmt.Printf("Warning: Could not sync %s: %v\n", s.CurrentProvider, err)
+			return
+		}
+		fmt.Printf("Synced %s.\n", s.CurrentProvider)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(rmCmd)
+}
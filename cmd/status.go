@@ -1,9 +1,15 @@
 package cmd
 
 import (
+	"aipad/internal/budget"
+	"aipad/internal/config"
 	"aipad/internal/state"
+	syncpkg "aipad/internal/sync"
 	"fmt"
+	"maps"
 	"os"
+	"path/filepath"
+	"slices"
 
 	"github.com/spf13/cobra"
 )
@@ -17,7 +23,10 @@ var statusCmd = &cobra.Command{
 - Session ID
 - Created at timestamp
 - Last sync timestamp
-- Number of context entries`,
+- Number of context entries
+- How the rules directory gets the scratchpad (copy, symlink or hard link)
+- Size of the managed block, and a warning for every provider whose
+  context does not fit its budget`,
 	Run: func(cmd *cobra.Command, args []string) {
 		s, err := state.Load()
 		if err != nil {
@@ -33,9 +42,117 @@ var statusCmd = &cobra.Command{
 		fmt.Printf("  Created:     %s\n", s.CreatedAt.Format("2006-01-02 15:04:05"))
 		fmt.Printf("  Last Sync:   %s\n", s.LastSync.Format("2006-01-02 15:04:05"))
 		fmt.Printf("  Entries:     %d context(s)\n", len(s.ContextHashes))
+
+		settings, err := config.LoadSettings()
+		if err != nil {
+			fmt.Printf("Warning: %v\n", err)
+			return
+		}
+		cwd, err := os.Getwd()
+		if err != nil {
+			return
+		}
+		scratchpadPath := filepath.Join(cwd, state.AIPadDir, state.ScratchpadFile)
+
+		printRules(s, settings, scratchpadPath)
+		printBudgets(s, settings, cwd, scratchpadPath)
 	},
 }
 
+// printRules shows how the current provider's rules directory gets the scratchpad
+func printRules(s *state.State, settings *config.Settings, scratchpadPath string) {
+	providerConfig, ok := s.Providers[s.CurrentProvider]
+	if !ok {
+		return
+	}
+	link, err := syncpkg.InspectRulesLink(scratchpadPath, providerConfig.RulesDir)
+	if err != nil {
+		fmt.Printf("Warning: Could not inspect %s: %v\n", link.Path, err)
+		return
+	}
+
+	var desc string
+	switch {
+	case link.Mode == "":
+		desc = "missing; run 'aipad sync'"
+	case link.Mode == config.LinkSymlink && link.Stale:
+		desc = fmt.Sprintf("symlink to %s, which is not the scratchpad; run 'aipad sync'", link.Target)
+	case link.Mode == config.LinkSymlink:
+		desc = "symlink to " + link.Target
+	case link.Mode == config.LinkHardlink:
+		desc = "hard link"
+	case link.Stale:
+		desc = "copy, out of date; run 'aipad sync'"
+	default:
+		desc = "copy"
+	}
+	if configured := settings.LinkFor(s.CurrentProvider); link.Mode != "" && link.Mode != configured {
+		desc += ", configured: " + configured
+	}
+	fmt.Printf("  Rules:       %s (%s)\n", link.Path, desc)
+}
+
+// printBudgets shows the size of the current provider's managed block and
+// warns about every synced provider whose context exceeds its budget
+func printBudgets(s *state.State, settings *config.Settings, cwd, scratchpadPath string) {
+	opts, err := blockOptions(s, s.CurrentProvider)
+	if err != nil {
+		fmt.Printf("Warning: %v\n", err)
+		return
+	}
+	current := settings.BudgetFor(s.CurrentProvider)
+	opts.Budget = current
+	block, err := syncpkg.BuildManagedBlock(scratchpadPath, opts)
+	if err != nil {
+		fmt.Printf("Warning: %v\n", err)
+		return
+	}
+	if current.Unlimited() {
+		fmt.Printf("  Context:     ~%d tokens (no budget)\n", budget.EstimateTokens(block.Content))
+	} else {
+		limit, unit := current.Limit()
+		fmt.Printf("  Context:     %s%d / %d %s, %d entries omitted\n", approx(unit), current.Size(block.Content), limit, unit, len(block.Omitted))
+	}
+
+	var warnings []string
+	providers := slices.Sorted(maps.Keys(s.Providers))
+	for _, provider := range providers {
+		b := settings.BudgetFor(provider)
+		if b.Unlimited() {
+			continue
+		}
+		// Only providers that have been synced have a managed block to check
+		if _, err := os.Stat(filepath.Join(cwd, s.Providers[provider].ConfigFile)); err != nil {
+			continue
+		}
+		opts.Provider, opts.Budget = provider, b
+		block, err := syncpkg.BuildManagedBlock(scratchpadPath, opts)
+		if err != nil {
+			continue
+		}
+		limit, unit := b.Limit()
+		if size := b.Size(block.Content); size > limit {
+			warnings = append(warnings, fmt.Sprintf("%s's managed block is %s%d %s, over its budget of %d even with every unpinned entry left out. Unpin entries, close tasks or raise the budget.", provider, approx(unit), size, unit, limit))
+		} else if len(block.Omitted) > 0 {
+			warnings = append(warnings, fmt.Sprintf("%s's context exceeds its budget of %s; %d entries are left out of its managed block.", provider, b, len(block.Omitted)))
+		}
+	}
+	if len(warnings) > 0 {
+		fmt.Println()
+		for _, w := range warnings {
+			fmt.Printf("Warning: %s\n", w)
+		}
+	}
+}
+
+// approx marks token counts, which are estimates
+func approx(unit string) string {
+	if unit == budget.UnitTokens {
+		return "~"
+	}
+	return ""
+}
+
 func init() {
 	rootCmd.AddCommand(statusCmd)
 }
@@ -1,11 +1,16 @@
 package cmd
 
 import (
+	"aipad/internal/config"
+	"aipad/internal/scratchpad"
 	"aipad/internal/state"
 	syncpkg "aipad/internal/sync"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+	"text/tabwriter"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -20,17 +25,49 @@ var syncCmd = &cobra.Command{
 This command will:
 - Read the current scratchpad content
 - Create the provider's rules directory if needed
-- Copy the scratchpad to the rules directory
+- Copy or link the scratchpad into the rules directory ("links" in
+  .aipad/config.json: copy, symlink or hardlink)
 - Update the provider's config file with the current context
 - Update the last_sync timestamp in state.json
 
-If no provider is specified, it syncs to the current provider.
+If no provider is specified, it syncs to the current provider. With --all,
+or with "sync_all": true in .aipad/config.json, it syncs every enabled
+provider at once. Providers that share a config file (ag and antigravity
+both use AGENTS.md) are written once, using the budget and link mode of the
+current provider if it is one of them. A summary table lists each target,
+and the command exits non-zero if any of them failed.
+
+aipad remembers what it last wrote to each managed block and rules copy.
+If an agent or a person edited them since, the edits are folded back into
+the scratchpad before the files are rewritten: edited entries are merged
+three-way with the scratchpad, text added outside entries becomes a new
+entry, and ticked tasks are closed. Edits that conflict with the
+scratchpad stop the sync. Use --strict to fail on any edit instead, or
+--force to overwrite the edits.
+
+The config file holds three managed blocks, each between its own markers:
+instructions (<!-- AIPAD:instructions -->), todos (<!-- AIPAD:todos -->)
+and context (<!-- AIPAD:context -->). Move them anywhere in the file and
+sync updates them in place; missing blocks are appended in that order. A
+single AIPAD_CONTEXT_START/END block from earlier versions is replaced by
+the three blocks.
+
+Use --tag and --exclude-tag to limit which entries are written into the
+config file's context block. Pinned entries are always included. The
+rules directory always receives the full scratchpad.
+
+If a budget is set for the provider in .aipad/config.json, the managed
+block is trimmed to fit it: pinned entries are always kept, then entries
+from the last 7 days, tagged entries and the rest are added, newest first,
+while they fit. A summary of what was left out points to the scratchpad.
 
 Valid providers are: claude, antigravity, ag
 
 Example:
   aipad sync
-  aipad sync antigravity`,
+  aipad sync antigravity
+  aipad sync --all
+  aipad sync --exclude-tag debug`,
 	Args: func(cmd *cobra.Command, args []string) error {
 		if len(args) > 1 {
 			return fmt.Errorf("accepts at most one argument: [provider]")
@@ -46,6 +83,12 @@ Example:
 		return nil
 	},
 	Run: func(cmd *cobra.Command, args []string) {
+		filter, err := filterFromFlags(cmd)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
 		// 1. Load existing state
 		s, err := state.Load()
 		if err != nil {
@@ -53,6 +96,26 @@ Example:
 			os.Exit(1)
 		}
 
+		settings, err := config.LoadSettings()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if syncStrict && syncForce {
+			fmt.Printf("Error: --strict and --force cannot be combined\n")
+			os.Exit(1)
+		}
+		if syncAllProviders && len(args) == 1 {
+			fmt.Printf("Error: --all cannot be combined with a provider\n")
+			os.Exit(1)
+		}
+		if syncAllProviders || (settings.SyncAll && len(args) == 0) {
+			if !syncAll(s, settings, filter) {
+				os.Exit(1)
+			}
+			return
+		}
+
 		// 2. Determine provider to sync to
 		provider := s.CurrentProvider
 		if len(args) == 1 {
@@ -60,54 +123,261 @@ Example:
 		}
 
 		fmt.Printf("Syncing context to provider: %s\n", provider)
+		defer commitJournal(beginJournal("sync", args, append(sessionPaths(), providerPaths(s, provider)...)...))
 
 		// 3. Get provider config
 		providerConfig, ok := s.Providers[provider]
 		if !ok {
 			fmt.Printf("Error: Provider configuration not found for '%s'\n", provider)
-			os.Exit(1)
+			exit(1)
+		}
+
+		// 3.1 Fold edits made to the provider's files since the last sync into the scratchpad
+		if !syncForce {
+			if err := foldDrift(s, providerConfig.ConfigFile, []string{providerConfig.RulesDir}, syncStrict); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				exit(1)
+			}
 		}
 
 		// 4. Create rules directory
 		if err := syncpkg.EnsureRulesDir(providerConfig.RulesDir); err != nil {
 			fmt.Printf("Error creating rules directory: %v\n", err)
-			os.Exit(1)
+			exit(1)
 		}
 		fmt.Printf("Ensured rules directory: %s\n", providerConfig.RulesDir)
 
-		// 5. Copy scratchpad to rules directory
+		// 5. Link or copy scratchpad into rules directory
 		cwd, err := os.Getwd()
 		if err != nil {
 			fmt.Printf("Error getting current directory: %v\n", err)
-			os.Exit(1)
+			exit(1)
 		}
 		scratchpadPath := filepath.Join(cwd, state.AIPadDir, state.ScratchpadFile)
 
-		if err := syncpkg.CopyScratchpadToRules(scratchpadPath, providerConfig.RulesDir); err != nil {
-			fmt.Printf("Error copying scratchpad: %v\n", err)
-			os.Exit(1)
+		mode, err := linkRules(provider, providerConfig.RulesDir, scratchpadPath)
+		if err != nil {
+			fmt.Printf("Error linking scratchpad: %v\n", err)
+			exit(1)
 		}
-		fmt.Printf("Copied scratchpad to %s\n", providerConfig.RulesDir)
+		fmt.Println(describeLink(mode, providerConfig.RulesDir))
 
 		// 6. Update config file with managed block
+		opts, err := blockOptions(s, provider)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(1)
+		}
+		b := settings.BudgetFor(provider)
+		opts.Filter, opts.Budget = filter, b
 		configPath := filepath.Join(cwd, providerConfig.ConfigFile)
-		if err := syncpkg.SyncProviderConfig(configPath, scratchpadPath); err != nil {
+		block, err := syncpkg.SyncProviderConfig(configPath, scratchpadPath, opts)
+		if err != nil {
 			fmt.Printf("Error updating config file: %v\n", err)
-			os.Exit(1)
+			exit(1)
 		}
 		fmt.Printf("Updated %s with current context\n", providerConfig.ConfigFile)
+		reportOmitted(provider, block, b)
 
 		// 7. Update last sync timestamp
 		s.LastSync = time.Now()
 		if err := s.Save(); err != nil {
 			fmt.Printf("Error saving state: %v\n", err)
-			os.Exit(1)
+			exit(1)
 		}
 
 		fmt.Printf("\nSync complete! Last sync: %s\n", s.LastSync.Format("2006-01-02 15:04:05"))
 	},
 }
 
+// syncTarget is a config file written by sync --all, with the rules
+// directories of the providers that share it
+type syncTarget struct {
+	Providers  []string
+	ConfigFile string
+	RulesDirs  []string
+	// Provider is the one whose budget and link mode apply
+	Provider string
+}
+
+// syncResult is the outcome of syncing one target
+type syncResult struct {
+	// Modes holds the link mode used for each rules directory
+	Modes   []string
+	Omitted int
+	Err     error
+}
+
+// syncTargets groups the enabled providers by config file. Each rules
+// directory is assigned to the first target that uses it, so no file is
+// written twice.
+func syncTargets(s *state.State) []syncTarget {
+	var targets []syncTarget
+	byFile := make(map[string]int)
+	seenRules := make(map[string]bool)
+	for _, name := range s.EnabledProviders() {
+		p := s.Providers[name]
+		file := filepath.Clean(p.ConfigFile)
+		i, ok := byFile[file]
+		if !ok {
+			i = len(targets)
+			byFile[file] = i
+			targets = append(targets, syncTarget{ConfigFile: p.ConfigFile, Provider: name})
+		}
+		t := &targets[i]
+		t.Providers = append(t.Providers, name)
+		if name == s.CurrentProvider {
+			t.Provider = name
+		}
+		if rules := filepath.Clean(p.RulesDir); !seenRules[rules] {
+			seenRules[rules] = true
+			t.RulesDirs = append(t.RulesDirs, p.RulesDir)
+		}
+	}
+	return targets
+}
+
+// syncAll writes every enabled provider concurrently and prints a summary.
+// It reports whether every target succeeded.
+func syncAll(s *state.State, settings *config.Settings, filter scratchpad.Filter) bool {
+	targets := syncTargets(s)
+	var paths []string
+	for _, t := range targets {
+		for _, name := range t.Providers {
+			paths = append(paths, providerPaths(s, name)...)
+		}
+	}
+	op := beginJournal("sync", []string{"--all"}, append(sessionPaths(), paths...)...)
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		fmt.Printf("Error getting current directory: %v\n", err)
+		exit(1)
+	}
+	scratchpadPath := filepath.Join(cwd, state.AIPadDir, state.ScratchpadFile)
+	opts, err := blockOptions(s, s.CurrentProvider)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		exit(1)
+	}
+	opts.Filter = filter
+
+	fmt.Printf("Syncing context to %d provider(s)...\n\n", len(s.EnabledProviders()))
+
+	// Folding edits back changes the scratchpad, so it happens before any target is written
+	results := make([]syncResult, len(targets))
+	if !syncForce {
+		for i, t := range targets {
+			results[i].Err = foldDrift(s, t.ConfigFile, t.RulesDirs, syncStrict)
+		}
+	}
+
+	// Targets share no files, so they can be written concurrently
+	var wg sync.WaitGroup
+	for i, t := range targets {
+		if results[i].Err != nil {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = syncOne(t, settings, cwd, scratchpadPath, opts)
+		}()
+	}
+	wg.Wait()
+
+	// Summary table
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  PROVIDER\tCONFIG\tRULES\tRESULT")
+	failed := 0
+	for i, t := range targets {
+		res := results[i]
+		result := "synced"
+		if res.Err != nil {
+			failed++
+			result = "failed: " + res.Err.Error()
+		} else {
+			var notes []string
+			for _, mode := range res.Modes {
+				if mode != config.LinkCopy {
+					notes = append(notes, mode)
+				}
+			}
+			if res.Omitted > 0 {
+				notes = append(notes, fmt.Sprintf("%d omitted", res.Omitted))
+			}
+			if len(notes) > 0 {
+				result += " (" + strings.Join(notes, ", ") + ")"
+			}
+		}
+		rules := strings.Join(t.RulesDirs, ", ")
+		if rules == "" {
+			rules = "-"
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", strings.Join(t.Providers, ", "), t.ConfigFile, rules, result)
+	}
+	w.Flush()
+
+	if failed < len(targets) {
+		s.LastSync = time.Now()
+		if err := s.Save(); err != nil {
+			fmt.Printf("Error saving state: %v\n", err)
+			failed = len(targets)
+		}
+	}
+	commitJournal(op)
+
+	if failed > 0 {
+		fmt.Printf("\nSync failed for %d of %d target(s).\n", failed, len(targets))
+		return false
+	}
+	fmt.Printf("\nSync complete! Last sync: %s\n", s.LastSync.Format("2006-01-02 15:04:05"))
+	return true
+}
+
+// syncOne links the scratchpad into a target's rules directories and
+// updates its config file with opts, set to the target's provider and budget.
+// It prints nothing, so targets can run concurrently.
+func syncOne(t syncTarget, settings *config.Settings, cwd, scratchpadPath string, opts syncpkg.Options) syncResult {
+	var res syncResult
+	mode := settings.LinkFor(t.Provider)
+	for _, rulesDir := range t.RulesDirs {
+		if err := syncpkg.EnsureRulesDir(rulesDir); err != nil {
+			res.Err = fmt.Errorf("failed to create rules directory: %w", err)
+			return res
+		}
+		used, err := syncpkg.LinkScratchpadToRules(scratchpadPath, rulesDir, mode)
+		if err != nil {
+			res.Err = fmt.Errorf("failed to link scratchpad: %w", err)
+			return res
+		}
+		if used != mode {
+			used = config.LinkCopy + ", " + mode + " rejected"
+		}
+		res.Modes = append(res.Modes, used)
+	}
+
+	configPath := filepath.Join(cwd, t.ConfigFile)
+	opts.Provider, opts.Budget = t.Provider, settings.BudgetFor(t.Provider)
+	block, err := syncpkg.SyncProviderConfig(configPath, scratchpadPath, opts)
+	if err != nil {
+		res.Err = fmt.Errorf("failed to update config file: %w", err)
+		return res
+	}
+	res.Omitted = len(block.Omitted)
+	return res
+}
+
+var (
+	syncAllProviders bool
+	syncStrict       bool
+	syncForce        bool
+)
+
 func init() {
 	rootCmd.AddCommand(syncCmd)
+	addTagFilterFlags(syncCmd)
+	syncCmd.Flags().BoolVar(&syncAllProviders, "all", false, "sync every enabled provider")
+	syncCmd.Flags().BoolVar(&syncStrict, "strict", false, "fail instead of merging edits made to synced files since the last sync")
+	syncCmd.Flags().BoolVar(&syncForce, "force", false, "overwrite edits made to synced files since the last sync")
 }
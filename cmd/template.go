@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"aipad/internal/instructions"
+	"aipad/internal/state"
+	syncpkg "aipad/internal/sync"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+// templateCmd represents the template command
+var templateCmd = &cobra.Command{
+	Use:   "template",
+	Short: "Preview the agent instructions templates",
+	Long: `Work with the templates that render the instructions block of each
+provider's config file.
+
+aipad ships a template for each builtin provider and a default one for
+custom providers, which does not assume the agent can run commands. Override them with Go text/template files in
+.aipad/templates/: <provider>.tmpl for one provider, or default.tmpl for
+every provider without its own. The project's templates win over the
+builtin ones.
+
+Templates can use:
+  {{.Provider}}     the provider name
+  {{.ConfigFile}}   the provider's config file
+  {{.RulesDir}}     the provider's rules directory
+  {{.SessionID}}    the session ID
+  {{.Entries}}      the number of synced entries
+  {{.Kinds.note}}   the number of synced entries of a kind (decision,
+                    todo, bug, note)
+  {{.Pinned}}       the number of pinned entries
+  {{.Todos}}        the open tasks, each with .ID, .Text and .Owner
+  {{plural .Entries "entry"}}  a count with its noun, e.g. "3 entries"
+
+Example:
+  aipad template render claude`,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("Use 'aipad template --help' to see available subcommands")
+	},
+}
+
+// templateRenderCmd represents the template render command
+var templateRenderCmd = &cobra.Command{
+	Use:   "render <provider>",
+	Short: "Show a provider's rendered instructions",
+	Long: `Render the instructions template for a provider with the current session
+and print it, along with the template it came from. Nothing is written.
+
+Example:
+  aipad template render claude
+  aipad template render ag`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("requires exactly one argument: <provider>")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		provider := args[0]
+
+		// 1. Load existing state
+		s, err := state.Load()
+		if err != nil {
+			fmt.Printf("Error: No active session found. Run 'aipad new <provider>' first.\n")
+			os.Exit(1)
+		}
+		if _, ok := s.Providers[provider]; !ok {
+			fmt.Printf("Error: unsupported provider: '%s'. Use 'aipad providers list' to see available providers\n", provider)
+			os.Exit(1)
+		}
+
+		// 2. Find the template
+		t, err := instructions.Find(provider)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		// 3. Render it the way sync does
+		cwd, err := os.Getwd()
+		if err != nil {
+			fmt.Printf("Error getting current directory: %v\n", err)
+			os.Exit(1)
+		}
+		scratchpadPath := filepath.Join(cwd, state.AIPadDir, state.ScratchpadFile)
+		opts, err := blockOptions(s, provider)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		block, err := syncpkg.BuildManagedBlock(scratchpadPath, opts)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Template: %s\n\n", t.Source)
+		fmt.Print(block.Parts[syncpkg.BlockInstructions])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(templateCmd)
+	templateCmd.AddCommand(templateRenderCmd)
+}
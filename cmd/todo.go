@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"aipad/internal/author"
+	"aipad/internal/state"
+	"aipad/internal/todo"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	todoOwner   string
+	todoEntries []string
+	todoAll     bool
+)
+
+// todoCmd represents the todo command
+var todoCmd = &cobra.Command{
+	Use:   "todo",
+	Short: "Manage the project's task list",
+	Long: `Manage the task list stored in .aipad/todos.json.
+
+Open tasks are rendered as a checklist in the todos managed block of
+every provider's config file, so the assistant you switch to knows what
+was in flight. Agents can close tasks with 'aipad todo done'.
+
+Example:
+  aipad todo add "Wire the retry policy into the gRPC client"
+  aipad todo add --owner alice --entry 3f2a9c1d "Migrate the session table"
+  aipad todo list
+  aipad todo done 7b1e`,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println("Use 'aipad todo --help' to see available subcommands")
+	},
+}
+
+// todoAddCmd represents the todo add command
+var todoAddCmd = &cobra.Command{
+	Use:   "add \"<text>\"",
+	Short: "Add a task",
+	Long: `Add an open task to the task list and re-sync the current provider.
+
+Use --owner to assign the task and --entry to link it to the scratchpad
+entries it relates to.
+
+Example:
+  aipad todo add "Wire the retry policy into the gRPC client"
+  aipad todo add --owner alice --entry 3f2a9c1d "Migrate the session table"`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 || strings.TrimSpace(args[0]) == "" {
+			return fmt.Errorf("requires exactly one argument: the task text")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		// 1. Load existing state and tasks
+		s, l := loadTodos()
+		defer commitJournal(beginJournal("todo add", args, append(sessionPaths(), providerPaths(s, s.CurrentProvider)...)...))
+
+		// 2. Resolve linked entries to their full IDs
+		var entries []string
+		for _, id := range todoEntries {
+			i, err := s.FindEntry(id)
+			if err != nil {
+				fmt.Printf("Error: cannot link entry: %v\n", err)
+				exit(1)
+			}
+			entries = append(entries, s.Entries[i].ID)
+		}
+
+		// 3. Record the task
+		item := l.Add(strings.TrimSpace(args[0]), todoOwner, entries)
+		item.CreatedBy = author.Detect(s.CurrentProvider, "").String()
+		id := item.ID
+		if err := l.Save(); err != nil {
+			fmt.Printf("Error saving todos: %v\n", err)
+			exit(1)
+		}
+		fmt.Printf("Added task %s.\n", id)
+
+		syncTodos(s)
+	},
+}
+
+// todoDoneCmd represents the todo done command
+var todoDoneCmd = &cobra.Command{
+	Use:   "done <id>...",
+	Short: "Mark tasks as done",
+	Long: `Mark one or more tasks as done and re-sync the current provider.
+Done tasks are kept in .aipad/todos.json but no longer synced.
+
+Example:
+  aipad todo done 7b1e
+  aipad todo done 7b1e 04c2`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		// 1. Load existing state and tasks
+		s, l := loadTodos()
+		defer commitJournal(beginJournal("todo done", args, append(sessionPaths(), providerPaths(s, s.CurrentProvider)...)...))
+
+		// 2. Resolve every ID before changing anything
+		var indexes []int
+		for _, id := range args {
+			i, err := l.Find(id)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				exit(1)
+			}
+			indexes = append(indexes, i)
+		}
+
+		// 3. Close the tasks
+		by := author.Detect(s.CurrentProvider, "").String()
+		changed := false
+		for _, i := range indexes {
+			item := l.Items[i]
+			if item.Status == todo.StatusDone {
+				fmt.Printf("Task %s is already done.\n", item.ID)
+				continue
+			}
+			l.Done(i, by)
+			changed = true
+			fmt.Printf("Done: %s (%s)\n", item.Text, item.ID)
+		}
+		if !changed {
+			return
+		}
+		if err := l.Save(); err != nil {
+			fmt.Printf("Error saving todos: %v\n", err)
+			exit(1)
+		}
+
+		syncTodos(s)
+	},
+}
+
+// todoListCmd represents the todo list command
+var todoListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List tasks",
+	Long: `List the open tasks, oldest first. Use --all to include done tasks
+and --owner to show only one person's tasks.
+
+Example:
+  aipad todo list
+  aipad todo list --all --owner alice`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		l, err := todo.Load()
+		if err != nil {
+			fmt.Printf("Error loading todos: %v\n", err)
+			os.Exit(1)
+		}
+
+		items := l.Open()
+		if todoAll {
+			items = l.Items
+		}
+		shown := 0
+		for _, item := range items {
+			if todoOwner != "" && !strings.EqualFold(item.Owner, todoOwner) {
+				continue
+			}
+			shown++
+
+			box := "[ ]"
+			if item.Status == todo.StatusDone {
+				box = "[x]"
+			}
+			fmt.Printf("  %s %s  (id: %s)\n", box, item.Text, item.ID)
+			if item.Owner != "" {
+				fmt.Printf("      owner: %s\n", item.Owner)
+			}
+			if len(item.Entries) > 0 {
+				fmt.Printf("      see:   %s\n", strings.Join(item.Entries, ", "))
+			}
+			if item.DoneAt != nil {
+				fmt.Printf("      done:  %s by %s\n", item.DoneAt.Format("2006-01-02 15:04:05"), item.DoneBy)
+			}
+		}
+		if shown == 0 {
+			fmt.Println("No tasks found.")
+		}
+	},
+}
+
+// loadTodos loads the session state and the task list, exiting on failure
+func loadTodos() (*state.State, *todo.List) {
+	s, err := state.Load()
+	if err != nil {
+		fmt.Printf("Error: No active session found. Run 'aipad new <provider>' first.\n")
+		os.Exit(1)
+	}
+	l, err := todo.Load()
+	if err != nil {
+		fmt.Printf("Error loading todos: %v\n", err)
+		os.Exit(1)
+	}
+	return s, l
+}
+
+// syncTodos re-syncs the current provider so its checklist matches the task list
+func syncTodos(s *state.State) {
+	if err := syncProvider(s, s.CurrentProvider); err != nil {
+		fmt.Printf("Warning: Could not sync %s: %v\n", s.CurrentProvider, err)
+		return
+	}
+	fmt.Printf("Synced %s.\n", s.CurrentProvider)
+}
+
+func init() {
+	rootCmd.AddCommand(todoCmd)
+	todoCmd.AddCommand(todoAddCmd)
+	todoCmd.AddCommand(todoDoneCmd)
+	todoCmd.AddCommand(todoListCmd)
+
+	todoAddCmd.Flags().StringVar(&todoOwner, "owner", "", "assign the task to someone")
+	todoAddCmd.Flags().StringSliceVar(&todoEntries, "entry", nil, "link the task to a scratchpad entry (repeatable)")
+	todoListCmd.Flags().StringVar(&todoOwner, "owner", "", "only show tasks assigned to this owner")
+	todoListCmd.Flags().BoolVar(&todoAll, "all", false, "include done tasks")
+}
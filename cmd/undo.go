@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"aipad/internal/journal"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// undoCmd represents the undo command
+var undoCmd = &cobra.Command{
+	Use:   "undo",
+	Short: "Roll back the last operation",
+	Long: `Roll back the most recent journaled operation (convo, edit, rm, pin,
+unpin, fmt, use, sync, clean or new) by restoring every file it changed
+to its previous contents. Run it again to step further back.
+
+Example:
+  aipad undo
+  aipad history`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		// 1. Find the last operation
+		op, err := journal.Last()
+		if err != nil {
+			fmt.Printf("Error: Failed to read journal: %v\n", err)
+			os.Exit(1)
+		}
+		if op == nil {
+			fmt.Println("Nothing to undo.")
+			return
+		}
+
+		// 2. Restore its before-images
+		changed := op.ChangedFiles()
+		if err := op.Restore(); err != nil {
+			fmt.Printf("Error: Failed to undo %s: %v\n", op.Command, err)
+			os.Exit(1)
+		}
+
+		// 3. Drop it from the journal so the next undo steps further back
+		if err := op.Delete(); err != nil {
+			fmt.Printf("Error: Failed to update journal: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Undid: %s\n", describeOperation(op))
+		for _, f := range changed {
+			if f.Existed {
+				fmt.Printf("  restored %s\n", f.Path)
+			} else {
+				fmt.Printf("  removed  %s\n", f.Path)
+			}
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(undoCmd)
+}
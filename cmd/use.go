@@ -19,7 +19,8 @@ var useCmd = &cobra.Command{
 This command will:
 - Update the current provider in state.json
 - Create the provider's rules directory if needed
-- Copy the scratchpad to the rules directory
+- Copy or link the scratchpad into the rules directory ("links" in
+  .aipad/config.json: copy, symlink or hardlink)
 - Update the provider's config file with the current context
 
 Valid providers are: claude, antigravity, ag
@@ -47,12 +48,13 @@ Example:
 			fmt.Printf("Error: No active session found. Run 'aipad new <provider>' first.\n")
 			os.Exit(1)
 		}
+		defer commitJournal(beginJournal("use", args, append(sessionPaths(), providerPaths(s, provider)...)...))
 
 		// 2. Update current provider
 		s.CurrentProvider = provider
 		if err := s.Save(); err != nil {
 			fmt.Printf("Error saving state: %v\n", err)
-			os.Exit(1)
+			exit(1)
 		}
 		fmt.Printf("Switched to provider: %s\n", provider)
 
@@ -60,37 +62,57 @@ Example:
 		providerConfig, ok := s.Providers[provider]
 		if !ok {
 			fmt.Printf("Error: Provider configuration not found for '%s'\n", provider)
-			os.Exit(1)
+			exit(1)
+		}
+
+		// 3.1 Fold edits made to the provider's files since the last sync into the scratchpad
+		if err := foldDrift(s, providerConfig.ConfigFile, []string{providerConfig.RulesDir}, false); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(1)
 		}
 
 		// 4. Create rules directory
 		if err := syncpkg.EnsureRulesDir(providerConfig.RulesDir); err != nil {
 			fmt.Printf("Error creating rules directory: %v\n", err)
-			os.Exit(1)
+			exit(1)
 		}
 		fmt.Printf("Ensured rules directory: %s\n", providerConfig.RulesDir)
 
-		// 5. Copy scratchpad to rules directory
+		// 5. Link or copy scratchpad into rules directory
 		cwd, err := os.Getwd()
 		if err != nil {
 			fmt.Printf("Error getting current directory: %v\n", err)
-			os.Exit(1)
+			exit(1)
 		}
 		scratchpadPath := filepath.Join(cwd, state.AIPadDir, state.ScratchpadFile)
 
-		if err := syncpkg.CopyScratchpadToRules(scratchpadPath, providerConfig.RulesDir); err != nil {
-			fmt.Printf("Error copying scratchpad: %v\n", err)
-			os.Exit(1)
+		mode, err := linkRules(provider, providerConfig.RulesDir, scratchpadPath)
+		if err != nil {
+			fmt.Printf("Error linking scratchpad: %v\n", err)
+			exit(1)
 		}
-		fmt.Printf("Copied scratchpad to %s\n", providerConfig.RulesDir)
+		fmt.Println(describeLink(mode, providerConfig.RulesDir))
 
 		// 6. Update config file with managed block
+		opts, err := blockOptions(s, provider)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(1)
+		}
+		b, err := providerBudget(provider)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			exit(1)
+		}
+		opts.Budget = b
 		configPath := filepath.Join(cwd, providerConfig.ConfigFile)
-		if err := syncpkg.SyncProviderConfig(configPath, scratchpadPath); err != nil {
+		block, err := syncpkg.SyncProviderConfig(configPath, scratchpadPath, opts)
+		if err != nil {
 			fmt.Printf("Error updating config file: %v\n", err)
-			os.Exit(1)
+			exit(1)
 		}
 		fmt.Printf("Updated %s with current context\n", providerConfig.ConfigFile)
+		reportOmitted(provider, block, b)
 
 		fmt.Println("\nProvider switch complete! The AI assistant should now have access to your context.")
 	},
@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"aipad/internal/config"
+	"aipad/internal/state"
+	"aipad/internal/watch"
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// watchCmd represents the watch command
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Sync providers whenever the scratchpad changes",
+	Long: `Watch .aipad/scratchpad.md and .aipad/state.json and re-sync whenever
+they change, so the rules directories and managed blocks never lag behind
+entries that agents add mid-session.
+
+Changes are debounced: a burst of writes, such as an agent saving several
+entries in a row, causes a single sync once the files have been quiet for
+the --debounce period. The current provider is re-read on every sync, so
+'aipad use' is picked up. With --all, or "sync_all": true in
+.aipad/config.json, every enabled provider is synced.
+
+Syncs made by watch are not journaled: they only regenerate files from the
+scratchpad, and 'aipad undo' should revert the change that triggered them.
+Press Ctrl+C to stop; a pending change is synced before exiting.
+
+Example:
+  aipad watch
+  aipad watch --all --debounce 2s`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if watchInterval <= 0 || watchDebounce < 0 {
+			fmt.Printf("Error: --interval must be positive and --debounce cannot be negative\n")
+			os.Exit(1)
+		}
+
+		// 1. Check the session and settings before starting
+		if _, err := state.Load(); err != nil {
+			fmt.Printf("Error: No active session found. Run 'aipad new <provider>' first.\n")
+			os.Exit(1)
+		}
+		settings, err := config.LoadSettings()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		all := watchAll || settings.SyncAll
+
+		cwd, err := os.Getwd()
+		if err != nil {
+			fmt.Printf("Error getting current directory: %v\n", err)
+			os.Exit(1)
+		}
+		paths := []string{
+			filepath.Join(cwd, state.AIPadDir, state.ScratchpadFile),
+			filepath.Join(cwd, state.AIPadDir, state.StateType),
+		}
+
+		// 2. Bring everything up to date, then watch for changes
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		poller := watch.NewPoller(paths...)
+		watchSync("initial sync", all, poller)
+		poller.Reset()
+
+		target := "the current provider"
+		if all {
+			target = "all providers"
+		}
+		fmt.Printf("Watching %s and %s; syncing %s on change. Press Ctrl+C to stop.\n",
+			filepath.Join(state.AIPadDir, state.ScratchpadFile), filepath.Join(state.AIPadDir, state.StateType), target)
+
+		watch.Watch(ctx, poller, watchInterval, watchDebounce, func(changed []string) {
+			var names []string
+			for _, path := range changed {
+				names = append(names, filepath.Base(path))
+			}
+			watchSync(strings.Join(names, ", ")+" changed", all, poller)
+		})
+		fmt.Println("Stopped watching.")
+	},
+}
+
+// watchSync runs one sync for watch and logs it on a single line per target.
+// Failures are logged and watching continues. The state it saves is passed to
+// poller, so recording the sync time does not trigger another sync.
+func watchSync(reason string, all bool, poller *watch.Poller) {
+	stamp := time.Now().Format("15:04:05")
+
+	s, err := state.Load()
+	if err != nil {
+		fmt.Printf("[%s] %s; sync failed: %v\n", stamp, reason, err)
+		return
+	}
+	settings, err := config.LoadSettings()
+	if err != nil {
+		fmt.Printf("[%s] %s; sync failed: %v\n", stamp, reason, err)
+		return
+	}
+
+	synced := false
+	if all {
+		cwd, err := os.Getwd()
+		if err != nil {
+			fmt.Printf("[%s] %s; sync failed: %v\n", stamp, reason, err)
+			return
+		}
+		scratchpadPath := filepath.Join(cwd, state.AIPadDir, state.ScratchpadFile)
+		opts, err := blockOptions(s, s.CurrentProvider)
+		if err != nil {
+			fmt.Printf("[%s] %s; sync failed: %v\n", stamp, reason, err)
+			return
+		}
+		for _, t := range syncTargets(s) {
+			res := syncResult{Err: foldDrift(s, t.ConfigFile, t.RulesDirs, false)}
+			if res.Err == nil {
+				res = syncOne(t, settings, cwd, scratchpadPath, opts)
+			}
+			if res.Err != nil {
+				fmt.Printf("[%s] %s; sync of %s failed: %v\n", stamp, reason, strings.Join(t.Providers, ", "), res.Err)
+				continue
+			}
+			synced = true
+			fmt.Printf("[%s] %s; synced %s (%s)\n", stamp, reason, strings.Join(t.Providers, ", "), t.ConfigFile)
+		}
+	} else {
+		if err := syncProvider(s, s.CurrentProvider); err != nil {
+			fmt.Printf("[%s] %s; sync of %s failed: %v\n", stamp, reason, s.CurrentProvider, err)
+		} else {
+			synced = true
+			fmt.Printf("[%s] %s; synced %s (%s)\n", stamp, reason, s.CurrentProvider, s.Providers[s.CurrentProvider].ConfigFile)
+		}
+	}
+
+	if synced {
+		s.LastSync = time.Now()
+		statePath, err := state.GetStatePath()
+		var data []byte
+		if err == nil {
+			data, err = s.Marshal()
+		}
+		if err == nil {
+			poller.Expect(statePath, data)
+			err = s.Save()
+		}
+		if err != nil {
+			fmt.Printf("[%s] Warning: Could not save state: %v\n", stamp, err)
+		}
+	}
+}
+
+var (
+	watchAll      bool
+	watchInterval time.Duration
+	watchDebounce time.Duration
+)
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+	watchCmd.Flags().BoolVar(&watchAll, "all", false, "sync every enabled provider")
+	watchCmd.Flags().DurationVar(&watchInterval, "interval", 250*time.Millisecond, "how often to check the files")
+	watchCmd.Flags().DurationVar(&watchDebounce, "debounce", 500*time.Millisecond, "how long the files must be quiet before syncing")
+}
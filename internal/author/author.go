@@ -0,0 +1,103 @@
+package author
+
+import (
+	"os"
+	"os/exec"
+	"os/user"
+	"strings"
+)
+
+// AgentEnvVar lets any assistant identify itself when it runs aipad
+const AgentEnvVar = "AIPAD_AGENT"
+
+// Source values
+const (
+	SourceAgent = "agent"
+	SourceHuman = "human"
+)
+
+// knownAgentEnvVars maps environment variables set by assistant CLIs to agent names
+var knownAgentEnvVars = []struct {
+	EnvVar string
+	Agent  string
+}{
+	{"CLAUDECODE", "claude-code"},
+	{"GEMINI_CLI", "gemini-cli"},
+}
+
+// Author describes who wrote an entry
+type Author struct {
+	// Provider is the session's current provider when the entry was written
+	Provider string
+	// User is the git user name, or the OS user if git is not configured
+	User string
+	// Agent names the assistant that wrote the entry; empty for humans
+	Agent string
+}
+
+// Source reports whether the entry came from an agent or a human
+func (a Author) Source() string {
+	if a.Agent != "" {
+		return SourceAgent
+	}
+	return SourceHuman
+}
+
+// String renders the author as "user" or "user (agent: name)"
+func (a Author) String() string {
+	if a.Agent != "" {
+		return a.User + " (agent: " + a.Agent + ")"
+	}
+	return a.User
+}
+
+// Detect builds the author of an entry being written now.
+// agent overrides detection when set, e.g. from an --agent flag.
+func Detect(provider, agent string) Author {
+	return Author{
+		Provider: provider,
+		User:     detectUser(),
+		Agent:    Sanitize(detectAgent(agent)),
+	}
+}
+
+func detectAgent(agent string) string {
+	if agent != "" {
+		return agent
+	}
+	if name := os.Getenv(AgentEnvVar); name != "" {
+		return name
+	}
+	for _, known := range knownAgentEnvVars {
+		if os.Getenv(known.EnvVar) != "" {
+			return known.Agent
+		}
+	}
+	return ""
+}
+
+func detectUser() string {
+	if out, err := exec.Command("git", "config", "user.name").Output(); err == nil {
+		if name := strings.TrimSpace(string(out)); name != "" {
+			return Sanitize(name)
+		}
+	}
+	if u, err := user.Current(); err == nil {
+		return Sanitize(u.Username)
+	}
+	return ""
+}
+
+// Sanitize strips characters that would break an entry header attribute: list
+// and group delimiters, '#', which starts a tag, and '<' and '>', which could
+// form a marker
+func Sanitize(value string) string {
+	value = strings.Map(func(r rune) rune {
+		switch r {
+		case ',', '(', ')', '#', '<', '>', '\n', '\r':
+			return ' '
+		}
+		return r
+	}, value)
+	return strings.Join(strings.Fields(value), " ")
+}
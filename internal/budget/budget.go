@@ -0,0 +1,107 @@
+package budget
+
+import (
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Units a budget can be expressed in
+const (
+	UnitTokens = "tokens"
+	UnitBytes  = "bytes"
+)
+
+// charsPerToken is roughly how many letters or digits of a word make up one token
+const charsPerToken = 4
+
+// Budget caps the size of a provider's managed block. At most one of Tokens
+// and Bytes is set; the zero Budget is unlimited.
+type Budget struct {
+	Tokens int `json:"tokens,omitempty"`
+	Bytes  int `json:"bytes,omitempty"`
+}
+
+// Validate checks that the budget is non-negative and uses a single unit
+func (b Budget) Validate() error {
+	if b.Tokens < 0 || b.Bytes < 0 {
+		return fmt.Errorf("invalid budget: tokens and bytes cannot be negative")
+	}
+	if b.Tokens > 0 && b.Bytes > 0 {
+		return fmt.Errorf("invalid budget: set either tokens or bytes, not both")
+	}
+	return nil
+}
+
+// Unlimited reports whether the budget sets no limit
+func (b Budget) Unlimited() bool {
+	return b.Tokens == 0 && b.Bytes == 0
+}
+
+// Limit returns the budget's limit and the unit it is counted in
+func (b Budget) Limit() (int, string) {
+	if b.Bytes > 0 {
+		return b.Bytes, UnitBytes
+	}
+	return b.Tokens, UnitTokens
+}
+
+// Size measures text in the budget's unit
+func (b Budget) Size(text string) int {
+	if b.Bytes > 0 {
+		return len(text)
+	}
+	return EstimateTokens(text)
+}
+
+// Fits reports whether text stays within the budget
+func (b Budget) Fits(text string) bool {
+	if b.Unlimited() {
+		return true
+	}
+	limit, _ := b.Limit()
+	return b.Size(text) <= limit
+}
+
+// String describes the budget, e.g. "4000 tokens"
+func (b Budget) String() string {
+	if b.Unlimited() {
+		return "unlimited"
+	}
+	limit, unit := b.Limit()
+	return fmt.Sprintf("%d %s", limit, unit)
+}
+
+// EstimateTokens approximates how many tokens a model's tokenizer splits text
+// into, without depending on any particular tokenizer. Words count one token
+// per four letters or digits, every punctuation mark or symbol counts one, and
+// so does every CJK character. Whitespace is free. It is meant for budgeting,
+// not as an exact count.
+func EstimateTokens(text string) int {
+	tokens, word := 0, 0
+	endWord := func() {
+		if word > 0 {
+			tokens += (word + charsPerToken - 1) / charsPerToken
+			word = 0
+		}
+	}
+
+	for len(text) > 0 {
+		r, size := utf8.DecodeRuneInString(text)
+		text = text[size:]
+		switch {
+		case unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r):
+			endWord()
+			tokens++
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r):
+			word++
+		case unicode.IsSpace(r):
+			endWord()
+		default:
+			endWord()
+			tokens++
+		}
+	}
+	endWord()
+	return tokens
+}
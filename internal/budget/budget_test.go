@@ -0,0 +1,60 @@
+package budget
+
+import "testing"
+
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected int
+	}{
+		{"empty", "", 0},
+		{"whitespace", " \n\t ", 0},
+		{"short words", "Use pnpm", 2},
+		{"long word", "internationalization", 5},
+		{"punctuation", "Done.", 2},
+		{"markdown", "- **Use** `pnpm`", 9},
+		{"cjk", "使用数据库", 5},
+		{"accents", "café naïve", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EstimateTokens(tt.text); got != tt.expected {
+				t.Errorf("EstimateTokens(%q) = %d, expected %d", tt.text, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBudget(t *testing.T) {
+	tests := []struct {
+		name    string
+		budget  Budget
+		text    string
+		fits    bool
+		invalid bool
+	}{
+		{name: "unlimited", budget: Budget{}, text: "anything at all", fits: true},
+		{name: "tokens within", budget: Budget{Tokens: 2}, text: "Use pnpm", fits: true},
+		{name: "tokens over", budget: Budget{Tokens: 1}, text: "Use pnpm", fits: false},
+		{name: "bytes within", budget: Budget{Bytes: 8}, text: "Use pnpm", fits: true},
+		{name: "bytes over", budget: Budget{Bytes: 7}, text: "Use pnpm", fits: false},
+		{name: "negative", budget: Budget{Tokens: -1}, invalid: true},
+		{name: "both units", budget: Budget{Tokens: 10, Bytes: 10}, invalid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.budget.Validate(); (err != nil) != tt.invalid {
+				t.Fatalf("Validate() error = %v, invalid %v", err, tt.invalid)
+			}
+			if tt.invalid {
+				return
+			}
+			if got := tt.budget.Fits(tt.text); got != tt.fits {
+				t.Errorf("Fits(%q) = %v, expected %v", tt.text, got, tt.fits)
+			}
+		})
+	}
+}
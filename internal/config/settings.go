@@ -0,0 +1,172 @@
+package config
+
+import (
+	"aipad/internal/budget"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const SettingsFile = "config.json"
+
+// Merge modes for content that is similar to an existing entry
+const (
+	MergeSkip    = "skip"
+	MergeAsk     = "ask"
+	MergeReplace = "replace"
+	MergeAppend  = "append"
+	MergeKeep    = "keep"
+)
+
+// MergeModes lists the valid merge modes
+var MergeModes = []string{MergeSkip, MergeAsk, MergeReplace, MergeAppend, MergeKeep}
+
+// Link modes for the scratchpad in a provider's rules directory
+const (
+	LinkCopy     = "copy"
+	LinkSymlink  = "symlink"
+	LinkHardlink = "hardlink"
+)
+
+// LinkModes lists the valid link modes
+var LinkModes = []string{LinkCopy, LinkSymlink, LinkHardlink}
+
+// Settings holds the project options read from .aipad/config.json
+type Settings struct {
+	// Merge is what convo does with near-duplicate content; empty means skip
+	Merge string `json:"merge,omitempty"`
+	// Similarity names the strategy used to detect near-duplicates:
+	// levenshtein (default), grapheme, jaccard or cosine
+	Similarity string `json:"similarity,omitempty"`
+	// Normalize lists extra normalization steps applied before comparing,
+	// in order: whitespace, markdown, punctuation
+	Normalize []string `json:"normalize,omitempty"`
+	// Threshold is the similarity from which content counts as a near-duplicate; 0 means the default
+	Threshold float64 `json:"threshold,omitempty"`
+	// MinLength is the length in characters below which only exact duplicates are rejected; nil means the default
+	MinLength *int `json:"min_length,omitempty"`
+	// Budgets caps the size of each provider's managed block, keyed by provider
+	// name; the "default" budget applies to providers without one of their own
+	Budgets map[string]budget.Budget `json:"budgets,omitempty"`
+	// Links sets how each provider's rules directory gets the scratchpad,
+	// keyed by provider name like Budgets: copy (default), symlink or hardlink
+	Links map[string]string `json:"links,omitempty"`
+	// SyncAll makes 'aipad sync' without a provider sync every enabled provider
+	SyncAll bool `json:"sync_all,omitempty"`
+}
+
+// DefaultProvider is the key in per-provider settings that applies to every
+// provider without an entry of its own
+const DefaultProvider = "default"
+
+// BudgetFor returns the managed block budget of provider
+func (s *Settings) BudgetFor(provider string) budget.Budget {
+	if b, ok := s.Budgets[provider]; ok {
+		return b
+	}
+	return s.Budgets[DefaultProvider]
+}
+
+// LinkFor returns the link mode of provider's rules directory
+func (s *Settings) LinkFor(provider string) string {
+	if mode, ok := s.Links[provider]; ok {
+		return mode
+	}
+	if mode, ok := s.Links[DefaultProvider]; ok {
+		return mode
+	}
+	return LinkCopy
+}
+
+// GetSettingsPath returns the path to the settings file.
+// Like providers.json, a project file takes precedence over the one in the home directory.
+func GetSettingsPath() (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	localPath := filepath.Join(cwd, AIPadConfigDir, SettingsFile)
+	if _, err := os.Stat(localPath); err == nil {
+		return localPath, nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, HomeConfigDir, SettingsFile), nil
+}
+
+// LoadSettings loads the settings, returning defaults if no settings file exists
+func LoadSettings() (*Settings, error) {
+	settingsPath, err := GetSettingsPath()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get settings path: %w", err)
+	}
+
+	data, err := os.ReadFile(settingsPath)
+	if os.IsNotExist(err) {
+		return &Settings{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read settings file: %w", err)
+	}
+
+	var settings Settings
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", settingsPath, err)
+	}
+	if settings.Merge != "" {
+		if err := ValidateMerge(settings.Merge); err != nil {
+			return nil, fmt.Errorf("%s: %w", settingsPath, err)
+		}
+	}
+	if settings.Threshold != 0 {
+		if err := ValidateThreshold(settings.Threshold); err != nil {
+			return nil, fmt.Errorf("%s: %w", settingsPath, err)
+		}
+	}
+	if settings.MinLength != nil && *settings.MinLength < 0 {
+		return nil, fmt.Errorf("%s: min_length cannot be negative", settingsPath)
+	}
+	for provider, b := range settings.Budgets {
+		if err := b.Validate(); err != nil {
+			return nil, fmt.Errorf("%s: budgets.%s: %w", settingsPath, provider, err)
+		}
+	}
+	for provider, mode := range settings.Links {
+		if err := ValidateLink(mode); err != nil {
+			return nil, fmt.Errorf("%s: links.%s: %w", settingsPath, provider, err)
+		}
+	}
+	return &settings, nil
+}
+
+// ValidateMerge checks that mode is one of MergeModes
+func ValidateMerge(mode string) error {
+	for _, m := range MergeModes {
+		if mode == m {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid merge mode '%s': must be one of skip, ask, replace, append, keep", mode)
+}
+
+// ValidateLink checks that mode is one of LinkModes
+func ValidateLink(mode string) error {
+	for _, m := range LinkModes {
+		if mode == m {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid link mode '%s': must be one of copy, symlink, hardlink", mode)
+}
+
+// ValidateThreshold checks that a similarity threshold is in (0, 1]
+func ValidateThreshold(threshold float64) error {
+	if threshold <= 0 || threshold > 1 {
+		return fmt.Errorf("invalid threshold %g: must be greater than 0 and at most 1", threshold)
+	}
+	return nil
+}
@@ -9,6 +9,11 @@ import (
 // SimilarityThreshold defines the minimum similarity ratio to consider content as duplicate
 const SimilarityThreshold = 0.80
 
+// MinSimilarityLength is the length in characters below which content is only
+// checked for exact duplicates. Short facts such as "Switched DB to Postgres 16"
+// differ from their predecessors by a character or two yet say something new.
+const MinSimilarityLength = 32
+
 // GenerateHash creates a SHA256 hash of the normalized content
 func GenerateHash(content string) string {
 	// Normalize: trim whitespace and lowercase
@@ -32,7 +37,8 @@ func IsDuplicate(hash string, existingHashes []string) bool {
 	return false
 }
 
-// LevenshteinDistance calculates the edit distance between two strings
+// LevenshteinDistance calculates the edit distance between two strings.
+// Only two rows of the matrix are kept, so memory is linear in len(b).
 func LevenshteinDistance(a, b string) int {
 	if len(a) == 0 {
 		return len(b)
@@ -41,38 +47,31 @@ func LevenshteinDistance(a, b string) int {
 		return len(a)
 	}
 
-	// Create matrix
-	matrix := make([][]int, len(a)+1)
-	for i := range matrix {
-		matrix[i] = make([]int, len(b)+1)
-	}
-
-	// Initialize first column
-	for i := 0; i <= len(a); i++ {
-		matrix[i][0] = i
-	}
-
 	// Initialize first row
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
 	for j := 0; j <= len(b); j++ {
-		matrix[0][j] = j
+		prev[j] = j
 	}
 
-	// Fill in the rest of the matrix
+	// Fill in the rest of the matrix row by row
 	for i := 1; i <= len(a); i++ {
+		cur[0] = i
 		for j := 1; j <= len(b); j++ {
 			cost := 0
 			if a[i-1] != b[j-1] {
 				cost = 1
 			}
-			matrix[i][j] = min(
-				matrix[i-1][j]+1,      // deletion
-				matrix[i][j-1]+1,      // insertion
-				matrix[i-1][j-1]+cost, // substitution
+			cur[j] = min(
+				prev[j]+1,      // deletion
+				cur[j-1]+1,     // insertion
+				prev[j-1]+cost, // substitution
 			)
 		}
+		prev, cur = cur, prev
 	}
 
-	return matrix[len(a)][len(b)]
+	return prev[len(b)]
 }
 
 // SimilarityRatio calculates the similarity between two strings (0.0 to 1.0)
@@ -93,13 +92,9 @@ func SimilarityRatio(a, b string) float64 {
 	return 1.0 - float64(distance)/float64(maxLen)
 }
 
-// IsSimilar checks if the new content is similar to any existing content
+// IsSimilar checks if the new content is similar to any existing content and
+// returns the first match. Callers that check repeatedly against the same
+// history should keep an Index instead.
 func IsSimilar(newContent string, existingContents []string, threshold float64) (bool, string, float64) {
-	for _, existing := range existingContents {
-		ratio := SimilarityRatio(newContent, existing)
-		if ratio >= threshold {
-			return true, existing, ratio
-		}
-	}
-	return false, "", 0
+	return NewIndex().Similar(newContent, existingContents, threshold)
 }
@@ -0,0 +1,369 @@
+package crypto
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"slices"
+)
+
+const (
+	// IndexFile is the name of the persisted similarity index inside .aipad
+	IndexFile = "similarity-index.json"
+
+	// IndexVersion changes whenever the index layout does; older indexes are rebuilt
+	IndexVersion = 2
+
+	// gramSize is the length in bytes of the q-grams the index records
+	gramSize = 3
+)
+
+// IndexDoc is the index record of one piece of content
+type IndexDoc struct {
+	// ID identifies the document in the gram postings
+	ID int `json:"id"`
+	// Length is the byte length of the normalized content
+	Length int `json:"length"`
+}
+
+// Index is a persisted q-gram index of the context history, keyed by content hash.
+//
+// It picks the entries that can possibly reach a similarity threshold before
+// any edit distance is computed. Grams maps every q-gram to the documents that
+// contain it, so the q-grams each document shares with new content are counted
+// from the postings of the new content's q-grams alone. Both filters are
+// exact: entries whose lengths differ by more than the allowed distance are
+// skipped, and so are entries that share too few q-grams to be within that
+// distance (Ukkonen's q-gram lemma). The remaining candidates are compared
+// with a banded edit distance that stops as soon as the distance exceeds the
+// allowed one. Decisions are therefore the same as comparing against every
+// entry with SimilarityRatio.
+type Index struct {
+	Version int                  `json:"version"`
+	Docs    map[string]*IndexDoc `json:"docs"`
+	// Grams maps each q-gram to the IDs of the documents containing it, once
+	// per occurrence and in ascending order
+	Grams  map[uint32][]int `json:"grams"`
+	NextID int              `json:"next_id"`
+
+	// hashes remembers the hash of every content passed to Update, so
+	// lookups do not hash the history again
+	hashes map[string]string
+}
+
+// NewIndex returns an empty index
+func NewIndex() *Index {
+	return &Index{Version: IndexVersion, Docs: make(map[string]*IndexDoc), Grams: make(map[uint32][]int)}
+}
+
+// LoadIndex reads the index at path. A missing, corrupt or outdated index
+// is not an error: an empty index is returned and rebuilt by Update.
+func LoadIndex(path string) (*Index, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return NewIndex(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var ix Index
+	if err := json.Unmarshal(data, &ix); err != nil || ix.Version != IndexVersion || ix.Docs == nil {
+		return NewIndex(), nil
+	}
+	if ix.Grams == nil {
+		ix.Grams = make(map[uint32][]int)
+	}
+	return &ix, nil
+}
+
+// Save writes the index to path
+func (ix *Index) Save(path string) error {
+	data, err := json.Marshal(ix)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// Update indexes any of contents that are missing and drops documents that
+// are no longer in contents. It reports whether the index changed.
+func (ix *Index) Update(contents []string) bool {
+	if ix.hashes == nil {
+		ix.hashes = make(map[string]string, len(contents))
+	}
+	changed := false
+	live := make(map[string]bool, len(contents))
+	for _, content := range contents {
+		hash := ix.hash(content)
+		live[hash] = true
+		if _, ok := ix.Docs[hash]; ok {
+			continue
+		}
+		normalized := Normalize(content)
+		doc := &IndexDoc{ID: ix.NextID, Length: len(normalized)}
+		ix.NextID++
+		ix.Docs[hash] = doc
+		for _, gram := range qgrams(normalized) {
+			ix.Grams[gram] = append(ix.Grams[gram], doc.ID)
+		}
+		changed = true
+	}
+
+	removed := make(map[int]bool)
+	for hash, doc := range ix.Docs {
+		if !live[hash] {
+			removed[doc.ID] = true
+			delete(ix.Docs, hash)
+		}
+	}
+	if len(removed) > 0 {
+		for gram, ids := range ix.Grams {
+			ids = slices.DeleteFunc(ids, func(id int) bool { return removed[id] })
+			if len(ids) == 0 {
+				delete(ix.Grams, gram)
+			} else {
+				ix.Grams[gram] = ids
+			}
+		}
+		changed = true
+	}
+	return changed
+}
+
+// hash returns the hash of content, remembering it for later lookups
+func (ix *Index) hash(content string) string {
+	if hash, ok := ix.hashes[content]; ok {
+		return hash
+	}
+	hash := GenerateHash(content)
+	if ix.hashes != nil {
+		ix.hashes[content] = hash
+	}
+	return hash
+}
+
+// Similar behaves like IsSimilar, returning the first of existingContents whose
+// similarity to newContent reaches threshold, but only computes edit distances
+// for candidates that pass the index filters. Contents missing from the index
+// are checked on the fly without modifying it.
+func (ix *Index) Similar(newContent string, existingContents []string, threshold float64) (bool, string, float64) {
+	a := Normalize(newContent)
+	grams := qgrams(a)
+	shared := ix.sharedCounts(grams)
+
+	for _, existing := range existingContents {
+		var ratio float64
+		var ok bool
+		if doc, indexed := ix.Docs[ix.hash(existing)]; indexed {
+			var k int
+			if k, ok = passes(len(a), doc.Length, shared[doc.ID], threshold); ok {
+				ratio, ok = verify(a, Normalize(existing), k)
+			}
+		} else {
+			ratio, ok = compare(a, grams, Normalize(existing), threshold)
+		}
+		if ok {
+			return true, existing, ratio
+		}
+	}
+	return false, "", 0
+}
+
+// sharedCounts returns, for every indexed document sharing q-grams with the
+// sorted grams, how many it shares, with multiplicity
+func (ix *Index) sharedCounts(grams []uint32) map[int]int {
+	shared := make(map[int]int)
+	for i := 0; i < len(grams); {
+		n := 1
+		for i+n < len(grams) && grams[i+n] == grams[i] {
+			n++
+		}
+		ids := ix.Grams[grams[i]]
+		for j := 0; j < len(ids); {
+			m := 1
+			for j+m < len(ids) && ids[j+m] == ids[j] {
+				m++
+			}
+			shared[ids[j]] += min(n, m)
+			j += m
+		}
+		i += n
+	}
+	return shared
+}
+
+// Compare returns the similarity of a and b if it reaches threshold, using the
+// same filters as Similar. It reports false if the similarity is lower.
+func (ix *Index) Compare(a, b string, threshold float64) (float64, bool) {
+	normalized := Normalize(a)
+	return compare(normalized, qgrams(normalized), Normalize(b), threshold)
+}
+
+// compare checks the normalized text b against the normalized text a, whose q-grams are grams
+func compare(a string, grams []uint32, b string, threshold float64) (float64, bool) {
+	// The length filter needs no q-grams, so it runs first
+	if _, ok := passes(len(a), len(b), math.MaxInt, threshold); !ok {
+		return 0, false
+	}
+	k, ok := passes(len(a), len(b), sharedGrams(grams, qgrams(b)), threshold)
+	if !ok {
+		return 0, false
+	}
+	return verify(a, b, k)
+}
+
+// passes applies the length and q-gram filters to normalized texts of
+// lengths aLen and bLen that share shared q-grams, and returns the edit
+// distance they may be apart to reach threshold
+func passes(aLen, bLen, shared int, threshold float64) (int, bool) {
+	maxLen := max(aLen, bLen)
+	k := maxDistance(maxLen, threshold)
+	if k < 0 {
+		return 0, false
+	}
+
+	// Length filter: the distance is at least the difference in length
+	if abs(aLen-bLen) > k {
+		return 0, false
+	}
+
+	// q-gram filter: within distance k, the strings share at least this many q-grams
+	if minShared := maxLen - gramSize + 1 - k*gramSize; minShared > 0 && shared < minShared {
+		return 0, false
+	}
+	return k, true
+}
+
+// verify returns the similarity of the normalized texts a and b if their
+// edit distance is at most k
+func verify(a, b string, k int) (float64, bool) {
+	maxLen := max(len(a), len(b))
+	if maxLen == 0 || a == b {
+		return 1.0, true
+	}
+	if d, ok := BoundedLevenshtein(a, b, k); ok {
+		return 1.0 - float64(d)/float64(maxLen), true
+	}
+	return 0, false
+}
+
+// maxDistance returns the largest edit distance at which two strings whose
+// longer one has maxLen bytes still reach threshold, or -1 if none does.
+// It mirrors the arithmetic of SimilarityRatio so boundary cases agree.
+func maxDistance(maxLen int, threshold float64) int {
+	if maxLen == 0 {
+		if 1.0 >= threshold {
+			return 0
+		}
+		return -1
+	}
+	ratio := func(d int) float64 { return 1.0 - float64(d)/float64(maxLen) }
+
+	k := int(math.Floor((1 - threshold) * float64(maxLen)))
+	k = min(max(k, -1), maxLen)
+	for k < maxLen && ratio(k+1) >= threshold {
+		k++
+	}
+	for k >= 0 && ratio(k) < threshold {
+		k--
+	}
+	return k
+}
+
+// BoundedLevenshtein computes the edit distance between a and b if it is at
+// most k, and reports false otherwise. It follows the diagonals of the edit
+// matrix within the band |i-j| <= k (Landau-Vishkin): for each error count
+// e it extends every diagonal as far as the strings match, so it costs
+// O(k^2) plus the length of the matching runs, and stops once e exceeds k.
+func BoundedLevenshtein(a, b string, k int) (int, bool) {
+	n, m := len(a), len(b)
+	if k < 0 || abs(n-m) > k {
+		return 0, false
+	}
+
+	// slide follows diagonal d from row i while the strings match
+	slide := func(i, d int) int {
+		for i < n && i+d < m && a[i] == b[i+d] {
+			i++
+		}
+		return i
+	}
+
+	// furthest[d+offset] is the furthest row reached on diagonal d (column j = i+d)
+	const unreached = math.MinInt / 2
+	offset := k + 1
+	furthest := make([]int, 2*k+3)
+	next := make([]int, 2*k+3)
+	for i := range furthest {
+		furthest[i] = unreached
+	}
+
+	furthest[offset] = slide(0, 0)
+	if n == m && furthest[offset] == n {
+		return 0, true
+	}
+
+	for e := 1; e <= k; e++ {
+		for i := range next {
+			next[i] = unreached
+		}
+		for d := max(-e, -n); d <= min(e, m); d++ {
+			row := max(
+				furthest[d+offset]+1,   // substitution
+				furthest[d+offset+1]+1, // deletion from a, coming from diagonal d+1
+				furthest[d+offset-1],   // insertion into a, coming from diagonal d-1
+			)
+			if row < 0 {
+				continue // Not reachable with e errors yet
+			}
+			// A step past the end of either string stays on the last valid row
+			row = min(row, n, m-d)
+			row = slide(row, d)
+			next[d+offset] = row
+			if d == m-n && row == n {
+				return e, true
+			}
+		}
+		furthest, next = next, furthest
+	}
+	return 0, false
+}
+
+// qgrams returns the sorted q-grams of s, each packed into a uint32
+func qgrams(s string) []uint32 {
+	if len(s) < gramSize {
+		return nil
+	}
+	grams := make([]uint32, 0, len(s)-gramSize+1)
+	for i := 0; i+gramSize <= len(s); i++ {
+		grams = append(grams, uint32(s[i])<<16|uint32(s[i+1])<<8|uint32(s[i+2]))
+	}
+	slices.Sort(grams)
+	return grams
+}
+
+// sharedGrams counts the q-grams two sorted gram lists have in common, with multiplicity
+func sharedGrams(a, b []uint32) int {
+	shared, i, j := 0, 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			shared++
+			i++
+			j++
+		case a[i] < b[j]:
+			i++
+		default:
+			j++
+		}
+	}
+	return shared
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,236 @@
+package crypto
+
+import (
+	"math/rand"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// naiveIsSimilar is the reference: a full-matrix edit distance against every entry in order
+func naiveIsSimilar(newContent string, existingContents []string, threshold float64) (bool, string, float64) {
+	for _, existing := range existingContents {
+		a, b := Normalize(newContent), Normalize(existing)
+		ratio := 1.0
+		if a != b {
+			ratio = 1.0 - float64(matrixLevenshtein(a, b))/float64(max(len(a), len(b)))
+		}
+		if ratio >= threshold {
+			return true, existing, ratio
+		}
+	}
+	return false, "", 0
+}
+
+// matrixLevenshtein fills the whole (len(a)+1)x(len(b)+1) matrix
+func matrixLevenshtein(a, b string) int {
+	matrix := make([][]int, len(a)+1)
+	for i := range matrix {
+		matrix[i] = make([]int, len(b)+1)
+		matrix[i][0] = i
+	}
+	for j := range matrix[0] {
+		matrix[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 0
+			if a[i-1] != b[j-1] {
+				cost = 1
+			}
+			matrix[i][j] = min(matrix[i-1][j]+1, matrix[i][j-1]+1, matrix[i-1][j-1]+cost)
+		}
+	}
+	return matrix[len(a)][len(b)]
+}
+
+var corpusWords = strings.Fields(`the api uses grpc for service calls sessions are stored in postgres
+redis caches tokens for an hour we moved from rest deploys run on friday tests must pass
+before merging the auth service validates jwt tokens migrations run at startup`)
+
+// randomText builds a sentence-like text of n words from corpusWords
+func randomText(r *rand.Rand, n int) string {
+	words := make([]string, n)
+	for i := range words {
+		words[i] = corpusWords[r.Intn(len(corpusWords))]
+	}
+	return strings.Join(words, " ")
+}
+
+// mutate applies up to edits random byte edits to s
+func mutate(r *rand.Rand, s string, edits int) string {
+	b := []byte(s)
+	for n := r.Intn(edits + 1); n > 0; n-- {
+		switch pos := r.Intn(len(b) + 1); r.Intn(3) {
+		case 0:
+			b = append(b[:pos], append([]byte{byte('a' + r.Intn(26))}, b[pos:]...)...)
+		case 1:
+			if pos < len(b) {
+				b = append(b[:pos], b[pos+1:]...)
+			}
+		default:
+			if pos < len(b) {
+				b[pos] = byte('a' + r.Intn(26))
+			}
+		}
+	}
+	return string(b)
+}
+
+func TestBoundedLevenshtein(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	binary := func(n int) string {
+		b := make([]byte, n)
+		for i := range b {
+			b[i] = "ab"[r.Intn(2)]
+		}
+		return string(b)
+	}
+
+	for i := 0; i < 4000; i++ {
+		var a, b string
+		switch r.Intn(3) {
+		case 0:
+			a, b = binary(r.Intn(9)), binary(r.Intn(9))
+		case 1:
+			a = randomText(r, r.Intn(6))
+			b = mutate(r, a, 8)
+		default:
+			a, b = randomText(r, r.Intn(6)), randomText(r, r.Intn(6))
+		}
+		k := r.Intn(12)
+
+		want := matrixLevenshtein(a, b)
+		got, ok := BoundedLevenshtein(a, b, k)
+		if ok != (want <= k) || (ok && got != want) {
+			t.Fatalf("BoundedLevenshtein(%q, %q, %d) = %d, %v; distance is %d", a, b, k, got, ok, want)
+		}
+	}
+}
+
+func TestIndexMatchesNaive(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	var history []string
+	for i := 0; i < 60; i++ {
+		history = append(history, randomText(r, 3+r.Intn(20)))
+	}
+	history = append(history, "", "ab", "API")
+
+	ix := NewIndex()
+	ix.Update(history)
+
+	thresholds := []float64{0, 0.5, 0.8, 0.95, 1, 1.1}
+	for i := 0; i < 1500; i++ {
+		var text string
+		switch r.Intn(3) {
+		case 0:
+			text = mutate(r, history[r.Intn(len(history))], 15)
+		case 1:
+			text = strings.ToUpper(history[r.Intn(len(history))])
+		default:
+			text = randomText(r, r.Intn(20))
+		}
+		threshold := thresholds[r.Intn(len(thresholds))]
+
+		wantOK, wantMatch, wantRatio := naiveIsSimilar(text, history, threshold)
+		gotOK, gotMatch, gotRatio := ix.Similar(text, history, threshold)
+		if gotOK != wantOK || gotMatch != wantMatch || gotRatio != wantRatio {
+			t.Fatalf("Similar(%q, %.2f) = %v, %q, %f; want %v, %q, %f", text, threshold, gotOK, gotMatch, gotRatio, wantOK, wantMatch, wantRatio)
+		}
+	}
+}
+
+func TestIndexPersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), IndexFile)
+	history := []string{"Sessions are stored in Postgres", "We use gRPC between services"}
+
+	ix, err := LoadIndex(path)
+	if err != nil {
+		t.Fatalf("LoadIndex() on a missing file error = %v", err)
+	}
+	if !ix.Update(history) {
+		t.Fatal("Update() on an empty index reported no change")
+	}
+	if err := ix.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := LoadIndex(path)
+	if err != nil {
+		t.Fatalf("LoadIndex() error = %v", err)
+	}
+	if loaded.Update(history) {
+		t.Error("Update() after a reload reported a change for the same history")
+	}
+	if !loaded.Update(history[1:]) || len(loaded.Docs) != 1 {
+		t.Errorf("Update() kept %d docs after an entry was removed, want 1", len(loaded.Docs))
+	}
+}
+
+// functionWords make up about half of the words in benchmark entries, as in prose
+var functionWords = strings.Fields(`the a an of to in on for with and or but is are was be
+it this that we they from by as at not use uses should must now all when then`)
+
+// benchmarkHistory returns n multi-paragraph entries of roughly 1.5 KB each.
+// Besides function words, entries draw on a vocabulary of a few thousand
+// terms, so unrelated entries share about as many q-grams as real notes do.
+func benchmarkHistory(n int) []string {
+	r := rand.New(rand.NewSource(3))
+	vocabulary := make([]string, 3000)
+	for i := range vocabulary {
+		word := make([]byte, 3+r.Intn(7))
+		for j := range word {
+			word[j] = byte('a' + r.Intn(26))
+		}
+		vocabulary[i] = string(word)
+	}
+
+	paragraph := func() string {
+		words := make([]string, 40)
+		for i := range words {
+			if r.Intn(2) == 0 {
+				words[i] = functionWords[r.Intn(len(functionWords))]
+			} else {
+				words[i] = vocabulary[r.Intn(len(vocabulary))]
+			}
+		}
+		return strings.Join(words, " ")
+	}
+
+	history := make([]string, n)
+	for i := range history {
+		history[i] = paragraph() + " " + paragraph() + "\n\n" + paragraph() + " " + paragraph()
+	}
+	return history
+}
+
+func BenchmarkIsSimilarNaive(b *testing.B) {
+	history := benchmarkHistory(300)
+	text := benchmarkHistory(301)[300]
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		naiveIsSimilar(text, history, SimilarityThreshold)
+	}
+}
+
+func BenchmarkIndexSimilar(b *testing.B) {
+	history := benchmarkHistory(300)
+	text := benchmarkHistory(301)[300]
+	ix := NewIndex()
+	ix.Update(history)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ix.Similar(text, history, SimilarityThreshold)
+	}
+}
+
+func BenchmarkIndexSimilarNearDuplicate(b *testing.B) {
+	history := benchmarkHistory(300)
+	text := history[299] + " and one more sentence"
+	ix := NewIndex()
+	ix.Update(history)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ix.Similar(text, history, SimilarityThreshold)
+	}
+}
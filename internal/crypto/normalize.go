@@ -0,0 +1,76 @@
+package crypto
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Normalization steps that can follow the default trim and lowercase
+const (
+	StepWhitespace  = "whitespace"
+	StepMarkdown    = "markdown"
+	StepPunctuation = "punctuation"
+)
+
+// NormalizeSteps lists the valid normalization steps
+var NormalizeSteps = []string{StepWhitespace, StepMarkdown, StepPunctuation}
+
+var normalizers = map[string]func(string) string{
+	StepWhitespace:  collapseWhitespace,
+	StepMarkdown:    stripMarkdown,
+	StepPunctuation: stripPunctuation,
+}
+
+// Pipeline is a sequence of normalization steps applied after Normalize
+type Pipeline []string
+
+// NewPipeline validates the named steps, which run in the order given
+func NewPipeline(steps []string) (Pipeline, error) {
+	for _, step := range steps {
+		if _, ok := normalizers[step]; !ok {
+			return nil, fmt.Errorf("invalid normalization step '%s': must be one of %s", step, strings.Join(NormalizeSteps, ", "))
+		}
+	}
+	return Pipeline(steps), nil
+}
+
+// Apply normalizes content: trim and lowercase, then each step in turn
+func (p Pipeline) Apply(content string) string {
+	content = Normalize(content)
+	for _, step := range p {
+		content = normalizers[step](content)
+	}
+	return strings.TrimSpace(content)
+}
+
+func collapseWhitespace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+var (
+	markdownFence  = regexp.MustCompile("(?m)^\\s*(```|~~~).*$")
+	markdownLink   = regexp.MustCompile(`!?\[([^\]]*)\]\([^)]*\)`)
+	markdownPrefix = regexp.MustCompile(`(?m)^\s*(#{1,6}\s+|>\s?|[-*+]\s+(\[[ x]\]\s+)?|\d+[.)]\s+)`)
+	markdownRule   = regexp.MustCompile(`(?m)^\s*([-*_]\s*){3,}$`)
+	markdownInline = strings.NewReplacer("**", "", "__", "", "~~", "", "`", "", "*", "")
+)
+
+// stripMarkdown removes markdown syntax, keeping the text it decorates
+func stripMarkdown(s string) string {
+	s = markdownFence.ReplaceAllString(s, "")
+	s = markdownRule.ReplaceAllString(s, "")
+	s = markdownLink.ReplaceAllString(s, "$1")
+	s = markdownPrefix.ReplaceAllString(s, "")
+	return markdownInline.Replace(s)
+}
+
+func stripPunctuation(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsPunct(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,336 @@
+package crypto
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Similarity strategy names
+const (
+	StrategyLevenshtein = "levenshtein"
+	StrategyGrapheme    = "grapheme"
+	StrategyJaccard     = "jaccard"
+	StrategyCosine      = "cosine"
+)
+
+// Strategies lists the valid similarity strategies
+var Strategies = []string{StrategyLevenshtein, StrategyGrapheme, StrategyJaccard, StrategyCosine}
+
+// Similarity scores how alike two normalized texts are
+type Similarity interface {
+	// Ratio returns a score from 0 (unrelated) to 1 (identical)
+	Ratio(a, b string) float64
+}
+
+// Levenshtein compares texts byte by byte with SimilarityRatio. It is the
+// default strategy and the only one the persisted Index accelerates.
+type Levenshtein struct{}
+
+func (Levenshtein) Ratio(a, b string) float64 {
+	return SimilarityRatio(a, b)
+}
+
+// Grapheme compares texts by edit distance over user-perceived characters,
+// so accented, CJK and emoji text is not skewed by its UTF-8 encoding
+type Grapheme struct{}
+
+func (Grapheme) Ratio(a, b string) float64 {
+	if a == b {
+		return 1.0
+	}
+	ga, gb := Graphemes(a), Graphemes(b)
+	maxLen := max(len(ga), len(gb))
+	if maxLen == 0 {
+		return 1.0
+	}
+	return 1.0 - float64(editDistance(ga, gb))/float64(maxLen)
+}
+
+// Jaccard compares the sets of word shingles (runs of Size consecutive words)
+// of two texts. Word order only matters within a shingle, so reordered
+// sentences still score high.
+type Jaccard struct {
+	Size int
+}
+
+func (j Jaccard) Ratio(a, b string) float64 {
+	sa, sb := shingles(Words(a), j.Size), shingles(Words(b), j.Size)
+	if len(sa) == 0 && len(sb) == 0 {
+		return 1.0
+	}
+	shared := 0
+	for s := range sa {
+		if sb[s] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(sa)+len(sb)-shared)
+}
+
+// Cosine compares TF-IDF weighted word vectors. Words that are common across
+// the corpus, such as "the" or the project name, count for less than rare ones.
+type Cosine struct {
+	idf map[string]float64
+	// defaultIDF weighs words that do not occur in the corpus
+	defaultIDF float64
+}
+
+// NewCosine builds the inverse document frequencies from corpus
+func NewCosine(corpus []string) *Cosine {
+	df := make(map[string]int)
+	for _, doc := range corpus {
+		seen := make(map[string]bool)
+		for _, w := range Words(doc) {
+			if !seen[w] {
+				seen[w] = true
+				df[w]++
+			}
+		}
+	}
+
+	n := float64(len(corpus))
+	c := &Cosine{idf: make(map[string]float64, len(df)), defaultIDF: math.Log(n+1) + 1}
+	for w, count := range df {
+		// Smoothed so words present in every document keep a small weight
+		c.idf[w] = math.Log((n+1)/(float64(count)+1)) + 1
+	}
+	return c
+}
+
+func (c *Cosine) Ratio(a, b string) float64 {
+	if a == b {
+		return 1.0
+	}
+	va, vb := c.vector(a), c.vector(b)
+	var dot, na, nb float64
+	for w, x := range va {
+		dot += x * vb[w]
+		na += x * x
+	}
+	for _, y := range vb {
+		nb += y * y
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+func (c *Cosine) vector(text string) map[string]float64 {
+	v := make(map[string]float64)
+	for _, w := range Words(text) {
+		idf, ok := c.idf[w]
+		if !ok {
+			idf = c.defaultIDF
+		}
+		v[w] += idf
+	}
+	return v
+}
+
+// NewSimilarity returns the named strategy. corpus is the normalized history,
+// which TF-IDF cosine uses for its word weights.
+func NewSimilarity(name string, corpus []string) (Similarity, error) {
+	switch name {
+	case "", StrategyLevenshtein:
+		return Levenshtein{}, nil
+	case StrategyGrapheme:
+		return Grapheme{}, nil
+	case StrategyJaccard:
+		return Jaccard{Size: 2}, nil
+	case StrategyCosine:
+		return NewCosine(corpus), nil
+	}
+	return nil, fmt.Errorf("invalid similarity strategy '%s': must be one of %s", name, strings.Join(Strategies, ", "))
+}
+
+// Matcher finds existing content that is similar to new content, using a
+// strategy and a normalization pipeline selected per project
+type Matcher struct {
+	Similarity Similarity
+	Pipeline   Pipeline
+	// Index, if set, accelerates the default Levenshtein strategy when the
+	// pipeline adds no steps; other configurations compare every entry
+	Index *Index
+}
+
+// NewMatcher builds a matcher for the named strategy and normalization steps.
+// corpus is the history the strategy will be compared against.
+func NewMatcher(strategy string, steps []string, corpus []string) (*Matcher, error) {
+	pipeline, err := NewPipeline(steps)
+	if err != nil {
+		return nil, err
+	}
+	normalized := make([]string, len(corpus))
+	for i, doc := range corpus {
+		normalized[i] = pipeline.Apply(doc)
+	}
+	similarity, err := NewSimilarity(strategy, normalized)
+	if err != nil {
+		return nil, err
+	}
+	return &Matcher{Similarity: similarity, Pipeline: pipeline}, nil
+}
+
+// Indexable reports whether the matcher can use an Index: only the default
+// Levenshtein strategy without extra normalization steps can
+func (m *Matcher) Indexable() bool {
+	_, ok := m.Similarity.(Levenshtein)
+	return ok && len(m.Pipeline) == 0
+}
+
+// Similar returns the first of existingContents whose similarity to
+// newContent reaches threshold, like IsSimilar
+func (m *Matcher) Similar(newContent string, existingContents []string, threshold float64) (bool, string, float64) {
+	if m.Indexable() {
+		if m.Index == nil {
+			return IsSimilar(newContent, existingContents, threshold)
+		}
+		return m.Index.Similar(newContent, existingContents, threshold)
+	}
+
+	a := m.Pipeline.Apply(newContent)
+	for _, existing := range existingContents {
+		if ratio := m.Similarity.Ratio(a, m.Pipeline.Apply(existing)); ratio >= threshold {
+			return true, existing, ratio
+		}
+	}
+	return false, "", 0
+}
+
+// Compare returns the similarity of a and b if it reaches threshold, and
+// reports false if it does not
+func (m *Matcher) Compare(a, b string, threshold float64) (float64, bool) {
+	if m.Indexable() {
+		ix := m.Index
+		if ix == nil {
+			ix = NewIndex()
+		}
+		return ix.Compare(a, b, threshold)
+	}
+	ratio := m.Ratio(a, b)
+	return ratio, ratio >= threshold
+}
+
+// Ratio returns the similarity of a and b after normalization
+func (m *Matcher) Ratio(a, b string) float64 {
+	return m.Similarity.Ratio(m.Pipeline.Apply(a), m.Pipeline.Apply(b))
+}
+
+// Words splits text into words. Letters and digits form words; Han, Hiragana
+// and Katakana characters are words on their own since those scripts are
+// written without spaces.
+func Words(text string) []string {
+	var words []string
+	start := -1
+	for i, r := range text {
+		switch {
+		case isIdeographic(r):
+			if start >= 0 {
+				words = append(words, text[start:i])
+				start = -1
+			}
+			words = append(words, string(r))
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r):
+			if start < 0 {
+				start = i
+			}
+		default:
+			if start >= 0 {
+				words = append(words, text[start:i])
+				start = -1
+			}
+		}
+	}
+	if start >= 0 {
+		words = append(words, text[start:])
+	}
+	return words
+}
+
+func isIdeographic(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana)
+}
+
+// Graphemes splits text into user-perceived characters: a base rune followed
+// by any combining marks, variation selectors, skin tone modifiers and
+// zero-width-joined runes, with regional indicators paired into flags.
+func Graphemes(text string) []string {
+	var clusters []string
+	start := 0
+	var prev rune
+	regional := 0
+	for i, r := range text {
+		if i > 0 && !extendsCluster(prev, r, regional) {
+			clusters = append(clusters, text[start:i])
+			start = i
+			regional = 0
+		}
+		if isRegionalIndicator(r) {
+			regional++
+		}
+		prev = r
+	}
+	if start < len(text) {
+		clusters = append(clusters, text[start:])
+	}
+	return clusters
+}
+
+func extendsCluster(prev, r rune, regional int) bool {
+	switch {
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc):
+		return true
+	case r == '\u200d' || prev == '\u200d':
+		return true
+	case r >= 0xfe00 && r <= 0xfe0f, r >= 0x1f3fb && r <= 0x1f3ff:
+		return true
+	case isRegionalIndicator(r) && isRegionalIndicator(prev):
+		return regional%2 == 1
+	}
+	return false
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1f1e6 && r <= 0x1f1ff
+}
+
+// shingles returns the set of runs of size consecutive words. Texts shorter
+// than size form a single shingle.
+func shingles(words []string, size int) map[string]bool {
+	set := make(map[string]bool)
+	if len(words) == 0 {
+		return set
+	}
+	if size < 1 || len(words) < size {
+		set[strings.Join(words, " ")] = true
+		return set
+	}
+	for i := 0; i+size <= len(words); i++ {
+		set[strings.Join(words[i:i+size], " ")] = true
+	}
+	return set
+}
+
+// editDistance is the Levenshtein distance between two sequences of clusters
+func editDistance(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 0
+			if a[i-1] != b[j-1] {
+				cost = 1
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,167 @@
+package crypto
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPipeline(t *testing.T) {
+	tests := []struct {
+		name     string
+		steps    []string
+		input    string
+		expected string
+	}{
+		{
+			name:     "default trims and lowercases",
+			input:    "  Use  PNPM  ",
+			expected: "use  pnpm",
+		},
+		{
+			name:     "whitespace",
+			steps:    []string{StepWhitespace},
+			input:    "Use\n\n  pnpm\tnot npm",
+			expected: "use pnpm not npm",
+		},
+		{
+			name:     "markdown",
+			steps:    []string{StepMarkdown, StepWhitespace},
+			input:    "## Tooling\n- Use **pnpm**, see [docs](https://pnpm.io)\n```\npnpm i\n```",
+			expected: "tooling use pnpm, see docs pnpm i",
+		},
+		{
+			name:     "punctuation",
+			steps:    []string{StepPunctuation},
+			input:    "Use pnpm, not npm!",
+			expected: "use pnpm not npm",
+		},
+		{
+			name:     "non-ascii punctuation",
+			steps:    []string{StepPunctuation},
+			input:    "「pnpm」を使う。",
+			expected: "pnpmを使う",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewPipeline(tt.steps)
+			if err != nil {
+				t.Fatalf("NewPipeline(%v) error = %v", tt.steps, err)
+			}
+			if got := p.Apply(tt.input); got != tt.expected {
+				t.Errorf("Apply(%q) = %q, want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+
+	if _, err := NewPipeline([]string{"stem"}); err == nil {
+		t.Error("NewPipeline accepted an unknown step")
+	}
+}
+
+func TestGraphemes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"abc", []string{"a", "b", "c"}},
+		{"café", []string{"c", "a", "f", "é"}},
+		{"日本語", []string{"日", "本", "語"}},
+		{"👍🏽!", []string{"👍🏽", "!"}},
+		{"👩‍💻x", []string{"👩‍💻", "x"}},
+		{"🇮🇩🇯🇵", []string{"🇮🇩", "🇯🇵"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := Graphemes(tt.input); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Graphemes(%q) = %q, want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWords(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"use pnpm, not npm", []string{"use", "pnpm", "not", "npm"}},
+		{"kami pakai Postgres 16", []string{"kami", "pakai", "Postgres", "16"}},
+		{"pnpmを使う", []string{"pnpm", "を", "使", "う"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := Words(tt.input); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Words(%q) = %q, want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestStrategies(t *testing.T) {
+	corpus := []string{
+		"we deploy on fridays after the tests pass",
+		"sessions are stored in postgres",
+		"the api uses grpc between services",
+	}
+
+	tests := []struct {
+		name     string
+		strategy string
+		a, b     string
+		min, max float64
+	}{
+		{"grapheme counts characters not bytes", StrategyGrapheme, "naïve café", "naive cafe", 0.8, 0.8},
+		{"byte levenshtein skews non-ascii", StrategyLevenshtein, "naïve café", "naive cafe", 0.6, 0.7},
+		{"grapheme cjk", StrategyGrapheme, "データベースを移行した", "データベースを移行しました", 0.8, 0.9},
+		{"grapheme identical", StrategyGrapheme, "café", "café", 1, 1},
+		{"jaccard ignores sentence order", StrategyJaccard, "use pnpm. run lint before pushing.", "run lint before pushing. use pnpm.", 0.6, 1},
+		{"jaccard unrelated", StrategyJaccard, "use pnpm for installs", "sessions are stored in postgres", 0, 0},
+		{"cosine ignores word order", StrategyCosine, "sessions are stored in postgres", "in postgres sessions are stored", 0.99, 1},
+		{"cosine weighs rare words", StrategyCosine, "the api uses grpc", "the api uses rest", 0.4, 0.7},
+		{"cosine unrelated", StrategyCosine, "use pnpm", "sessions are stored in postgres", 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewSimilarity(tt.strategy, corpus)
+			if err != nil {
+				t.Fatalf("NewSimilarity(%q) error = %v", tt.strategy, err)
+			}
+			if got := s.Ratio(tt.a, tt.b); got < tt.min || got > tt.max {
+				t.Errorf("%s Ratio(%q, %q) = %f, want between %f and %f", tt.strategy, tt.a, tt.b, got, tt.min, tt.max)
+			}
+		})
+	}
+
+	if _, err := NewSimilarity("soundex", nil); err == nil {
+		t.Error("NewSimilarity accepted an unknown strategy")
+	}
+}
+
+func TestMatcher(t *testing.T) {
+	history := []string{"Use **pnpm**, not npm.", "Sessions are stored in Postgres"}
+
+	m, err := NewMatcher(StrategyLevenshtein, []string{StepMarkdown, StepPunctuation}, history)
+	if err != nil {
+		t.Fatalf("NewMatcher() error = %v", err)
+	}
+	if m.Indexable() {
+		t.Error("Indexable() = true for a pipeline with extra steps")
+	}
+	ok, match, ratio := m.Similar("use pnpm not npm", history, 0.95)
+	if !ok || match != history[0] || ratio != 1 {
+		t.Errorf("Similar() = %v, %q, %f; want a full match on %q", ok, match, ratio, history[0])
+	}
+
+	m, err = NewMatcher("", nil, history)
+	if err != nil {
+		t.Fatalf("NewMatcher() error = %v", err)
+	}
+	if !m.Indexable() {
+		t.Error("Indexable() = false for the default matcher")
+	}
+}
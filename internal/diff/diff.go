@@ -0,0 +1,200 @@
+// Package diff computes word- and line-level differences between texts, and
+// merges two texts that were edited from a common base.
+package diff
+
+import (
+	"slices"
+	"strings"
+)
+
+// Op is the kind of change a Chunk represents
+type Op int
+
+const (
+	Equal Op = iota
+	Insert
+	Delete
+)
+
+// Chunk is a run of words that is kept, inserted or deleted
+type Chunk struct {
+	Op    Op
+	Words []string
+}
+
+// Words diffs old against new word by word. Whitespace only separates
+// words and is not part of the comparison.
+func Words(old, new string) []Chunk {
+	return diff(strings.Fields(old), strings.Fields(new))
+}
+
+// Lines diffs old against new line by line. Chunk.Words holds the lines.
+func Lines(old, new string) []Chunk {
+	return diff(splitLines(old), splitLines(new))
+}
+
+// diff computes the chunks that turn a into b. Common leading and trailing
+// items are matched first, so texts with a small edit stay cheap to compare.
+func diff(a, b []string) []Chunk {
+	var chunks []Chunk
+	add := func(op Op, items ...string) {
+		if len(items) == 0 {
+			return
+		}
+		if n := len(chunks); n > 0 && chunks[n-1].Op == op {
+			chunks[n-1].Words = append(chunks[n-1].Words, items...)
+			return
+		}
+		chunks = append(chunks, Chunk{Op: op, Words: append([]string(nil), items...)})
+	}
+
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	add(Equal, a[:prefix]...)
+	ma, mb := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	// lcs[i][j] is the length of the longest common subsequence of ma[i:] and mb[j:]
+	lcs := make([][]int, len(ma)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(mb)+1)
+	}
+	for i := len(ma) - 1; i >= 0; i-- {
+		for j := len(mb) - 1; j >= 0; j-- {
+			if ma[i] == mb[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(ma) && j < len(mb) {
+		switch {
+		case ma[i] == mb[j]:
+			add(Equal, ma[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			add(Delete, ma[i])
+			i++
+		default:
+			add(Insert, mb[j])
+			j++
+		}
+	}
+	add(Delete, ma[i:]...)
+	add(Insert, mb[j:]...)
+	add(Equal, a[len(a)-suffix:]...)
+	return chunks
+}
+
+// splitLines splits text into lines without their line breaks
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// Format renders chunks in the style of git's --word-diff: deleted words
+// as [-words-] and inserted words as {+words+}
+func Format(chunks []Chunk) string {
+	parts := make([]string, 0, len(chunks))
+	for _, c := range chunks {
+		text := strings.Join(c.Words, " ")
+		switch c.Op {
+		case Insert:
+			text = "{+" + text + "+}"
+		case Delete:
+			text = "[-" + text + "-]"
+		}
+		parts = append(parts, text)
+	}
+	return strings.Join(parts, " ")
+}
+
+// Inserted returns the inserted runs of words, one string per run
+func Inserted(chunks []Chunk) []string {
+	var runs []string
+	for _, c := range chunks {
+		if c.Op == Insert {
+			runs = append(runs, strings.Join(c.Words, " "))
+		}
+	}
+	return runs
+}
+
+// Merge3 merges the changes ours and theirs each made to base, line by line,
+// like diff3. It reports false if both changed the same lines differently;
+// the merged text is then incomplete and should not be used.
+func Merge3(base, ours, theirs string) (string, bool) {
+	b := splitLines(base)
+	o, oMatch := matchLines(b, splitLines(ours))
+	t, tMatch := matchLines(b, splitLines(theirs))
+
+	var merged []string
+	ok := true
+	// Walk the stable base lines, kept by both sides, and merge the hunks between them
+	bi, oi, ti := 0, 0, 0
+	for {
+		next := bi
+		for next < len(b) && (oMatch[next] < 0 || tMatch[next] < 0) {
+			next++
+		}
+		oEnd, tEnd := len(o), len(t)
+		if next < len(b) {
+			oEnd, tEnd = oMatch[next], tMatch[next]
+		}
+
+		baseHunk, ourHunk, theirHunk := b[bi:next], o[oi:oEnd], t[ti:tEnd]
+		switch {
+		case slices.Equal(ourHunk, baseHunk):
+			merged = append(merged, theirHunk...)
+		case slices.Equal(theirHunk, baseHunk), slices.Equal(ourHunk, theirHunk):
+			merged = append(merged, ourHunk...)
+		default:
+			ok = false
+		}
+
+		if next == len(b) {
+			break
+		}
+		merged = append(merged, b[next])
+		bi, oi, ti = next+1, oEnd+1, tEnd+1
+	}
+
+	if len(merged) == 0 {
+		return "", ok
+	}
+	return strings.Join(merged, "\n") + "\n", ok
+}
+
+// matchLines returns lines and, for every line of base, the index of the line
+// of lines it is kept as, or -1 if it was changed or removed
+func matchLines(base, lines []string) ([]string, []int) {
+	match := make([]int, len(base))
+	bi, li := 0, 0
+	for _, c := range diff(base, lines) {
+		for range c.Words {
+			switch c.Op {
+			case Equal:
+				match[bi] = li
+				bi++
+				li++
+			case Delete:
+				match[bi] = -1
+				bi++
+			case Insert:
+				li++
+			}
+		}
+	}
+	return lines, match
+}
@@ -0,0 +1,142 @@
+package diff
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWords(t *testing.T) {
+	tests := []struct {
+		name     string
+		old      string
+		new      string
+		expected string
+		inserted []string
+	}{
+		{
+			name:     "identical",
+			old:      "we use postgres",
+			new:      "we  use\npostgres",
+			expected: "we use postgres",
+		},
+		{
+			name:     "changed word",
+			old:      "We use Postgres 15 in production",
+			new:      "We use Postgres 16 in production",
+			expected: "We use Postgres [-15-] {+16+} in production",
+			inserted: []string{"16"},
+		},
+		{
+			name:     "appended sentence",
+			old:      "Sessions live in Redis.",
+			new:      "Sessions live in Redis. They expire after 24h.",
+			expected: "Sessions live in Redis. {+They expire after 24h.+}",
+			inserted: []string{"They expire after 24h."},
+		},
+		{
+			name:     "removed words",
+			old:      "Run the full test suite before merging",
+			new:      "Run the test suite",
+			expected: "Run the [-full-] test suite [-before merging-]",
+		},
+		{
+			name:     "empty old",
+			old:      "",
+			new:      "brand new",
+			expected: "{+brand new+}",
+			inserted: []string{"brand new"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := Words(tt.old, tt.new)
+			if got := Format(chunks); got != tt.expected {
+				t.Errorf("Format(Words(%q, %q)) = %q, want %q", tt.old, tt.new, got, tt.expected)
+			}
+			if got := Inserted(chunks); !reflect.DeepEqual(got, tt.inserted) {
+				t.Errorf("Inserted() = %q, want %q", got, tt.inserted)
+			}
+		})
+	}
+}
+
+func TestLines(t *testing.T) {
+	old := "a\nb\nc\nd\n"
+	new := "a\nB\nc\nd\ne\n"
+	expected := []Chunk{
+		{Op: Equal, Words: []string{"a"}},
+		{Op: Delete, Words: []string{"b"}},
+		{Op: Insert, Words: []string{"B"}},
+		{Op: Equal, Words: []string{"c", "d"}},
+		{Op: Insert, Words: []string{"e"}},
+	}
+	if got := Lines(old, new); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Lines() = %v, want %v", got, expected)
+	}
+}
+
+func TestMerge3(t *testing.T) {
+	base := "one\ntwo\nthree\nfour\nfive\n"
+	tests := []struct {
+		name     string
+		ours     string
+		theirs   string
+		expected string
+		ok       bool
+	}{
+		{
+			name:     "unchanged",
+			ours:     base,
+			theirs:   base,
+			expected: base,
+			ok:       true,
+		},
+		{
+			name:     "only theirs changed",
+			ours:     base,
+			theirs:   "one\nTWO\nthree\nfour\nfive\n",
+			expected: "one\nTWO\nthree\nfour\nfive\n",
+			ok:       true,
+		},
+		{
+			name:     "separate changes",
+			ours:     "one\ntwo\nthree\nfour\nfive\nsix\n",
+			theirs:   "one\nTWO\nthree\nfour\nfive\n",
+			expected: "one\nTWO\nthree\nfour\nfive\nsix\n",
+			ok:       true,
+		},
+		{
+			name:     "same change on both sides",
+			ours:     "one\ntwo\n3\nfour\nfive\n",
+			theirs:   "one\ntwo\n3\nfour\nfive\n",
+			expected: "one\ntwo\n3\nfour\nfive\n",
+			ok:       true,
+		},
+		{
+			name:     "insert and delete",
+			ours:     "zero\none\ntwo\nthree\nfour\nfive\n",
+			theirs:   "one\ntwo\nthree\nfive\n",
+			expected: "zero\none\ntwo\nthree\nfive\n",
+			ok:       true,
+		},
+		{
+			name:   "conflict",
+			ours:   "one\ntwo\nthree\n4\nfive\n",
+			theirs: "one\ntwo\nthree\nFOUR\nfive\n",
+			ok:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Merge3(base, tt.ours, tt.theirs)
+			if ok != tt.ok {
+				t.Fatalf("Merge3() ok = %v, want %v", ok, tt.ok)
+			}
+			if ok && got != tt.expected {
+				t.Errorf("Merge3() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package instructions
+
+import (
+	"aipad/internal/config"
+	"aipad/internal/state"
+	"aipad/internal/todo"
+	"embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+const (
+	// TemplatesDir holds the project's instruction templates, inside .aipad
+	TemplatesDir = "templates"
+
+	// TemplateExt is the extension of template files, e.g. claude.tmpl
+	TemplateExt = ".tmpl"
+)
+
+//go:embed builtin/*.tmpl
+var builtin embed.FS
+
+// aliases maps provider names to the provider whose templates they share
+var aliases = map[string]string{"ag": "antigravity"}
+
+// Data is what a template can use
+type Data struct {
+	// Provider is the provider the instructions are for
+	Provider string
+	// ConfigFile and RulesDir are the provider's files, relative to the project root
+	ConfigFile string
+	RulesDir   string
+	SessionID  string
+	// Entries is the number of entries synced; Kinds counts them by kind and
+	// Pinned counts the pinned ones
+	Entries int
+	Kinds   map[string]int
+	Pinned  int
+	// Todos holds the open tasks
+	Todos []todo.Item
+}
+
+// Template is the instructions template chosen for a provider
+type Template struct {
+	// Source is the template's path relative to the project root, or
+	// "builtin <name>" for a template shipped with aipad
+	Source string
+	Text   string
+}
+
+// GetTemplatesDir returns the path to the project's templates directory
+func GetTemplatesDir() (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cwd, state.AIPadDir, TemplatesDir), nil
+}
+
+// Find returns the template for provider. The project's own template for the
+// provider wins, then the project's default.tmpl, then the builtin template
+// for the provider, then the builtin default.
+func Find(provider string) (Template, error) {
+	var names []string
+	if provider != "" {
+		names = append(names, provider)
+	}
+	if alias, ok := aliases[provider]; ok {
+		names = append(names, alias)
+	}
+	names = append(names, config.DefaultProvider)
+
+	dir, err := GetTemplatesDir()
+	if err != nil {
+		return Template{}, err
+	}
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(dir, name+TemplateExt))
+		if err == nil {
+			return Template{Source: filepath.Join(state.AIPadDir, TemplatesDir, name+TemplateExt), Text: string(data)}, nil
+		}
+		if !os.IsNotExist(err) {
+			return Template{}, err
+		}
+	}
+	for _, name := range names {
+		if data, err := builtin.ReadFile("builtin/" + name + TemplateExt); err == nil {
+			return Template{Source: "builtin " + name, Text: string(data)}, nil
+		}
+	}
+	return Template{}, fmt.Errorf("no builtin %s template", config.DefaultProvider)
+}
+
+// Render executes the template with data. Unknown fields are an error, so
+// typos in a template show up on the first sync; kinds without entries count
+// as zero.
+func (t Template) Render(data Data) (string, error) {
+	tmpl, err := template.New(t.Source).Funcs(template.FuncMap{"plural": Plural}).Option("missingkey=zero").Parse(t.Text)
+	if err != nil {
+		return "", fmt.Errorf("invalid template: %w", err)
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to render template: %w", err)
+	}
+	return b.String(), nil
+}
+
+// Render finds the template for provider and executes it with data
+func Render(provider string, data Data) (string, error) {
+	t, err := Find(provider)
+	if err != nil {
+		return "", err
+	}
+	return t.Render(data)
+}
+
+// Plural formats a count with its noun, e.g. "1 entry" or "3 entries"
+func Plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", noun)
+	}
+	if strings.HasSuffix(noun, "y") {
+		return fmt.Sprintf("%d %sies", n, strings.TrimSuffix(noun, "y"))
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
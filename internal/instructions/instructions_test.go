@@ -0,0 +1,118 @@
+package instructions
+
+import (
+	"aipad/internal/todo"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	tests := []struct {
+		name     string
+		files    []string
+		provider string
+		expected string
+	}{
+		{name: "builtin provider", provider: "claude", expected: "builtin claude"},
+		{name: "alias", provider: "ag", expected: "builtin antigravity"},
+		{name: "custom provider", provider: "my-bot", expected: "builtin default"},
+		{name: "no provider", expected: "builtin default"},
+		{name: "project default wins over builtin", files: []string{"default"}, provider: "claude", expected: ".aipad/templates/default.tmpl"},
+		{name: "project provider wins over project default", files: []string{"default", "my-bot"}, provider: "my-bot", expected: ".aipad/templates/my-bot.tmpl"},
+		{name: "project template for an alias", files: []string{"antigravity"}, provider: "ag", expected: ".aipad/templates/antigravity.tmpl"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			templates := filepath.Join(dir, ".aipad", TemplatesDir)
+			os.RemoveAll(templates)
+			os.MkdirAll(templates, 0755)
+			for _, name := range tt.files {
+				os.WriteFile(filepath.Join(templates, name+TemplateExt), []byte(name), 0644)
+			}
+
+			got, err := Find(tt.provider)
+			if err != nil {
+				t.Fatalf("Find() error = %v", err)
+			}
+			if got.Source != tt.expected {
+				t.Errorf("Find(%q) = %s, expected %s", tt.provider, got.Source, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	data := Data{
+		Provider:  "claude",
+		RulesDir:  ".claude/rules/",
+		SessionID: "1234",
+		Entries:   3,
+		Kinds:     map[string]int{"decision": 1, "note": 2},
+		Todos:     []todo.Item{{ID: "7b1e", Text: "Migrate the table"}},
+	}
+
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+		err      bool
+	}{
+		{name: "fields", text: "{{.Provider}} {{.SessionID}} {{len .Todos}}", expected: "claude 1234 1"},
+		{name: "kinds", text: "{{.Kinds.decision}} {{.Kinds.bug}}", expected: "1 0"},
+		{name: "plural", text: `{{plural .Entries "entry"}}, {{plural .Kinds.decision "decision"}}`, expected: "3 entries, 1 decision"},
+		{name: "tasks", text: "{{range .Todos}}- {{.Text}} ({{.ID}}){{end}}", expected: "- Migrate the table (7b1e)"},
+		{name: "unknown field", text: "{{.Session}}", err: true},
+		{name: "parse error", text: "{{if .Todos}}", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Template{Source: "test", Text: tt.text}.Render(data)
+			if (err != nil) != tt.err {
+				t.Fatalf("Render() error = %v, expected error %v", err, tt.err)
+			}
+			if got != tt.expected {
+				t.Errorf("Render() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+
+	// Every builtin template renders, with and without data. Only providers
+	// with a terminal are told to run commands.
+	builtins := []struct {
+		provider string
+		save     string
+		shell    bool
+	}{
+		{provider: "claude", save: "aipad convo", shell: true},
+		{provider: "antigravity", save: "aipad convo", shell: true},
+		{provider: "default", save: `under the "Current Session Context" heading`},
+	}
+	for _, b := range builtins {
+		for _, d := range []Data{data, {}} {
+			got, err := Render(b.provider, d)
+			if err != nil {
+				t.Errorf("Render(%s) error = %v", b.provider, err)
+			}
+			if !strings.Contains(got, b.save) {
+				t.Errorf("Render(%s) does not explain how to save context:\n%s", b.provider, got)
+			}
+			if strings.Contains(got, "```bash") != b.shell {
+				t.Errorf("Render(%s) shell commands = %v, expected %v:\n%s", b.provider, !b.shell, b.shell, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,285 @@
+package journal
+
+import (
+	"aipad/internal/state"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// JournalDir is the directory under .aipad holding one file per operation
+	JournalDir = "journal"
+
+	// MaxOperations is the number of operations kept in the journal
+	MaxOperations = 50
+)
+
+// Snapshot is the before-image of a file touched by an operation
+type Snapshot struct {
+	// Path is relative to the project root
+	Path    string `json:"path"`
+	Existed bool   `json:"existed"`
+	Content []byte `json:"content,omitempty"`
+	// Link is the target of a symlink; the content it points to is not recorded
+	Link string `json:"link,omitempty"`
+}
+
+// Operation is a journaled run of a mutating command
+type Operation struct {
+	ID      string     `json:"id"`
+	Command string     `json:"command"`
+	Args    []string   `json:"args,omitempty"`
+	Time    time.Time  `json:"time"`
+	Files   []Snapshot `json:"files"`
+}
+
+// GetJournalPath returns the path to the journal directory
+func GetJournalPath() (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cwd, state.AIPadDir, JournalDir), nil
+}
+
+// Begin captures the before-images of paths for a command that is about to run.
+// Paths may be absolute or relative to the project root. Nothing is written
+// until Commit is called.
+func Begin(command string, args []string, paths ...string) (*Operation, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	op := &Operation{
+		ID:      fmt.Sprintf("%d", now.UnixNano()),
+		Command: command,
+		Args:    args,
+		Time:    now,
+	}
+
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		rel := path
+		if filepath.IsAbs(path) {
+			if rel, err = filepath.Rel(cwd, path); err != nil {
+				return nil, err
+			}
+		}
+		rel = filepath.Clean(rel)
+		if seen[rel] {
+			continue
+		}
+		seen[rel] = true
+
+		snapshot, err := take(cwd, rel)
+		if err != nil {
+			return nil, fmt.Errorf("failed to snapshot %s: %w", rel, err)
+		}
+		op.Files = append(op.Files, snapshot)
+	}
+
+	return op, nil
+}
+
+// Commit writes the operation to the journal if any of its files changed
+func (op *Operation) Commit() error {
+	if !op.changed() {
+		return nil
+	}
+
+	dir, err := GetJournalPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create journal directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(op, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, op.fileName()), data, 0644); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+
+	return prune(dir)
+}
+
+// changed reports whether any snapshotted file differs from its current content
+func (op *Operation) changed() bool {
+	return len(op.ChangedFiles()) > 0
+}
+
+// ChangedFiles returns the snapshots whose file differs from its current content
+func (op *Operation) ChangedFiles() []Snapshot {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return op.Files
+	}
+	var files []Snapshot
+	for _, f := range op.Files {
+		current, err := take(cwd, f.Path)
+		if err != nil || current.Existed != f.Existed || current.Link != f.Link || !bytes.Equal(current.Content, f.Content) {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+// take records the current state of the file at rel. A symlink is recorded
+// as its target so restoring it never writes through to the linked file.
+func take(cwd, rel string) (Snapshot, error) {
+	snapshot := Snapshot{Path: rel}
+	path := filepath.Join(cwd, rel)
+	if isSymlink(path) {
+		target, err := os.Readlink(path)
+		if err != nil {
+			return snapshot, err
+		}
+		snapshot.Existed = true
+		snapshot.Link = target
+		return snapshot, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err == nil {
+		snapshot.Existed = true
+		snapshot.Content = content
+	} else if !os.IsNotExist(err) {
+		return snapshot, err
+	}
+	return snapshot, nil
+}
+
+func isSymlink(path string) bool {
+	info, err := os.Lstat(path)
+	return err == nil && info.Mode()&os.ModeSymlink != 0
+}
+
+// Restore puts every file of the operation back to its before-image
+func (op *Operation) Restore() error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	for _, f := range op.Files {
+		path := filepath.Join(cwd, f.Path)
+		// Replace links instead of writing through them
+		if !f.Existed || f.Link != "" || isSymlink(path) {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove %s: %w", f.Path, err)
+			}
+		}
+		if !f.Existed {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if f.Link != "" {
+			if err := os.Symlink(f.Link, path); err != nil {
+				return fmt.Errorf("failed to restore %s: %w", f.Path, err)
+			}
+			continue
+		}
+		if err := os.WriteFile(path, f.Content, 0644); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", f.Path, err)
+		}
+	}
+	return nil
+}
+
+// fileName is the operation's file in the journal directory, e.g. "1760000000000000000-todo-add.json"
+func (op *Operation) fileName() string {
+	return op.ID + "-" + strings.ReplaceAll(op.Command, " ", "-") + ".json"
+}
+
+// Delete removes the operation from the journal
+func (op *Operation) Delete() error {
+	dir, err := GetJournalPath()
+	if err != nil {
+		return err
+	}
+	return os.Remove(filepath.Join(dir, op.fileName()))
+}
+
+// List returns the journaled operations, newest first
+func List() ([]*Operation, error) {
+	dir, err := GetJournalPath()
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := journalFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var ops []*Operation
+	for i := len(names) - 1; i >= 0; i-- {
+		data, err := os.ReadFile(filepath.Join(dir, names[i]))
+		if err != nil {
+			return nil, err
+		}
+		var op Operation
+		if err := json.Unmarshal(data, &op); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", names[i], err)
+		}
+		ops = append(ops, &op)
+	}
+	return ops, nil
+}
+
+// Last returns the most recent operation, or nil if the journal is empty
+func Last() (*Operation, error) {
+	ops, err := List()
+	if err != nil || len(ops) == 0 {
+		return nil, err
+	}
+	return ops[0], nil
+}
+
+// journalFiles returns the journal file names, oldest first
+func journalFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".json") {
+			names = append(names, e.Name())
+		}
+	}
+	// IDs are fixed-width nanosecond timestamps, so names sort chronologically
+	sort.Strings(names)
+	return names, nil
+}
+
+// prune drops the oldest operations beyond MaxOperations
+func prune(dir string) error {
+	names, err := journalFiles(dir)
+	if err != nil {
+		return err
+	}
+	for len(names) > MaxOperations {
+		if err := os.Remove(filepath.Join(dir, names[0])); err != nil {
+			return err
+		}
+		names = names[1:]
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package journal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func chdirTemp(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	return dir
+}
+
+func TestRestore(t *testing.T) {
+	dir := chdirTemp(t)
+	existing := filepath.Join(dir, "existing.md")
+	created := filepath.Join("rules", "created.md")
+	if err := os.WriteFile(existing, []byte("before"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	op, err := Begin("sync", []string{"claude"}, existing, created)
+	if err != nil {
+		t.Fatalf("Begin() error = %v", err)
+	}
+	os.WriteFile(existing, []byte("after"), 0644)
+	os.MkdirAll("rules", 0755)
+	os.WriteFile(created, []byte("new"), 0644)
+	if err := op.Commit(); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+
+	last, err := Last()
+	if err != nil || last == nil {
+		t.Fatalf("Last() = %v, %v; want the committed operation", last, err)
+	}
+	if last.Command != "sync" || len(last.Files) != 2 {
+		t.Fatalf("Last() = %+v, want sync with 2 files", last)
+	}
+	if err := last.Restore(); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	if content, _ := os.ReadFile(existing); string(content) != "before" {
+		t.Errorf("existing.md = %q, want %q", content, "before")
+	}
+	if _, err := os.Stat(created); !os.IsNotExist(err) {
+		t.Errorf("created.md still exists after Restore()")
+	}
+}
+
+func TestCommitSkipsUnchanged(t *testing.T) {
+	chdirTemp(t)
+	os.WriteFile("file.md", []byte("same"), 0644)
+
+	op, err := Begin("fmt", nil, "file.md")
+	if err != nil {
+		t.Fatalf("Begin() error = %v", err)
+	}
+	if err := op.Commit(); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+
+	if last, _ := Last(); last != nil {
+		t.Errorf("Last() = %+v, want nil for an operation that changed nothing", last)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	chdirTemp(t)
+
+	for i := 0; i < MaxOperations+5; i++ {
+		op, err := Begin("convo", nil, "file.md")
+		if err != nil {
+			t.Fatalf("Begin() error = %v", err)
+		}
+		os.WriteFile("file.md", []byte{byte(i)}, 0644)
+		if err := op.Commit(); err != nil {
+			t.Fatalf("Commit() error = %v", err)
+		}
+	}
+
+	ops, err := List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(ops) != MaxOperations {
+		t.Errorf("len(List()) = %d, want %d", len(ops), MaxOperations)
+	}
+}
+
+func TestRestoreSymlink(t *testing.T) {
+	chdirTemp(t)
+	os.WriteFile("original.md", []byte("original"), 0644)
+	if err := os.Symlink("original.md", "link.md"); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	op, err := Begin("sync", nil, "link.md")
+	if err != nil {
+		t.Fatalf("Begin() error = %v", err)
+	}
+	if op.Files[0].Link != "original.md" || op.Files[0].Content != nil {
+		t.Fatalf("snapshot = %+v, want the link target only", op.Files[0])
+	}
+
+	// Replacing the link with a copy is a change; restoring brings the link back
+	os.Remove("link.md")
+	os.WriteFile("link.md", []byte("copy"), 0644)
+	if !op.changed() {
+		t.Fatal("changed() = false after replacing the link")
+	}
+	if err := op.Restore(); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if target, err := os.Readlink("link.md"); err != nil || target != "original.md" {
+		t.Errorf("link.md = %q, %v, want a symlink to original.md", target, err)
+	}
+	if data, _ := os.ReadFile("original.md"); string(data) != "original" {
+		t.Errorf("original.md = %q, want it untouched", data)
+	}
+
+	// Restoring a copy over a link must not write through to the link's target
+	os.WriteFile("copy.md", []byte("before"), 0644)
+	op, _ = Begin("sync", nil, "copy.md")
+	os.Remove("copy.md")
+	os.Symlink("original.md", "copy.md")
+	if err := op.Restore(); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if data, _ := os.ReadFile("copy.md"); string(data) != "before" {
+		t.Errorf("copy.md = %q, want \"before\"", data)
+	}
+	if data, _ := os.ReadFile("original.md"); string(data) != "original" {
+		t.Errorf("original.md = %q, want it untouched", data)
+	}
+}
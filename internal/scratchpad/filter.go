@@ -0,0 +1,78 @@
+package scratchpad
+
+import (
+	"aipad/internal/author"
+	"strings"
+)
+
+// Filter selects entries by tag and attribution
+type Filter struct {
+	// Tags keeps entries carrying at least one of these tags; empty keeps all
+	Tags []string
+	// ExcludeTags drops entries carrying any of these tags
+	ExcludeTags []string
+	// Providers keeps entries written while one of these providers was active
+	Providers []string
+	// Authors keeps entries written by one of these users
+	Authors []string
+	// Source keeps only agent or only human entries; empty keeps both
+	Source string
+}
+
+// IsEmpty reports whether the filter keeps every entry
+func (f Filter) IsEmpty() bool {
+	return len(f.Tags) == 0 && len(f.ExcludeTags) == 0 &&
+		len(f.Providers) == 0 && len(f.Authors) == 0 && f.Source == ""
+}
+
+// Match reports whether the entry passes the filter
+func (f Filter) Match(e Entry) bool {
+	for _, tag := range f.ExcludeTags {
+		if e.HasTag(tag) {
+			return false
+		}
+	}
+	if len(f.Providers) > 0 && !containsFold(f.Providers, e.Provider) {
+		return false
+	}
+	if len(f.Authors) > 0 && !containsFold(f.Authors, e.Author) {
+		return false
+	}
+	if f.Source != "" && f.Source != e.Source() {
+		return false
+	}
+	if len(f.Tags) == 0 {
+		return true
+	}
+	for _, tag := range f.Tags {
+		if e.HasTag(tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// Select returns the entries that pass the filter
+func (f Filter) Select(entries []Entry) []Entry {
+	var result []Entry
+	for _, e := range entries {
+		if f.Match(e) {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// Source reports whether the entry was written by an agent or a human
+func (e Entry) Source() string {
+	return author.Author{Agent: e.Agent}.Source()
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
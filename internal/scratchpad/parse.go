@@ -0,0 +1,213 @@
+package scratchpad
+
+import (
+	"aipad/internal/crypto"
+	"aipad/internal/state"
+	"regexp"
+	"strings"
+)
+
+// headerPattern matches an entry header line: ## [timestamp] Title (attrs) #tags
+var headerPattern = regexp.MustCompile(`^##\s*\[([^\]]+)\]\s*(.*?)\s*$`)
+
+var idPattern = regexp.MustCompile(`^[0-9a-f]+$`)
+
+// line is a single line of the scratchpad with its byte offsets.
+// end points at the newline (or the end of the content), so the newline is excluded.
+type line struct {
+	text       string
+	start, end int
+}
+
+func splitLines(content string) []line {
+	var lines []line
+	start := 0
+	for start < len(content) {
+		end := strings.IndexByte(content[start:], '\n')
+		if end < 0 {
+			end = len(content)
+		} else {
+			end += start
+		}
+		lines = append(lines, line{
+			text:  strings.TrimSuffix(content[start:end], "\r"),
+			start: start,
+			end:   end,
+		})
+		start = end + 1
+	}
+	return lines
+}
+
+// Parse extracts all entries from the scratchpad content
+func Parse(content string) []Entry {
+	return ParseDocument(content).Entries
+}
+
+// ParseDocument splits the scratchpad content into its preamble and entries.
+// It never fails: text that is not part of an entry is kept in the preamble
+// or in the trailer of the entry it follows.
+func ParseDocument(content string) *Document {
+	doc := &Document{}
+	lines := splitLines(content)
+
+	i := nextHeader(lines, 0)
+	doc.Preamble = span(content, lines, 0, i)
+
+	for i < len(lines) {
+		entry := parseHeader(lines[i].text)
+		entry.start = lines[i].start
+		entry.end = lines[i].end
+		bodyStart := i + 1
+
+		// Canonical entry: the body runs up to the end marker carrying its ID
+		endLine := -1
+		if entry.ID != "" {
+			marker := endMarker(entry.ID)
+			for j := bodyStart; j < len(lines); j++ {
+				if strings.TrimSpace(lines[j].text) == marker {
+					endLine = j
+					break
+				}
+			}
+		}
+
+		var next int
+		if endLine >= 0 {
+			entry.Content = strings.TrimSpace(span(content, lines, bodyStart, endLine))
+			entry.end = lines[endLine].end
+			next = nextHeader(lines, endLine+1)
+			entry.Trailer = span(content, lines, endLine+1, next)
+		} else {
+			// Legacy entry: the body runs up to the next header, minus a trailing "---" separator
+			next = nextHeader(lines, bodyStart)
+			last := next - 1
+			for last >= bodyStart && strings.TrimSpace(lines[last].text) == "" {
+				last--
+			}
+			if last >= bodyStart {
+				entry.end = lines[last].end
+			}
+			if last >= bodyStart && strings.TrimSpace(lines[last].text) == "---" {
+				last--
+			}
+			entry.Content = strings.TrimSpace(span(content, lines, bodyStart, last+1))
+		}
+
+		if entry.ID == "" {
+			entry.ID = state.LegacyEntryID(crypto.GenerateHash(entry.Content))
+		}
+		doc.Entries = append(doc.Entries, entry)
+		i = next
+	}
+
+	return doc
+}
+
+// nextHeader returns the index of the first header line at or after from
+func nextHeader(lines []line, from int) int {
+	for i := from; i < len(lines); i++ {
+		if headerPattern.MatchString(lines[i].text) {
+			return i
+		}
+	}
+	return len(lines)
+}
+
+// span returns the content covered by lines[from:to]
+func span(content string, lines []line, from, to int) string {
+	if from >= to {
+		return ""
+	}
+	return content[lines[from].start:lines[to-1].end]
+}
+
+// parseHeader reads the metadata from an entry header line
+func parseHeader(text string) Entry {
+	m := headerPattern.FindStringSubmatch(text)
+	e := Entry{Timestamp: strings.TrimSpace(m[1])}
+
+	// Trailing #tags
+	fields := strings.Fields(m[2])
+	n := len(fields)
+	for n > 0 && len(fields[n-1]) > 1 && strings.HasPrefix(fields[n-1], "#") {
+		n--
+	}
+	for _, field := range fields[n:] {
+		e.Tags = append(e.Tags, strings.ToLower(field[1:]))
+	}
+	title := strings.Join(fields[:n], " ")
+
+	// Attributes in trailing parentheses. Only a group carrying an ID is
+	// treated as attributes so hand-written titles like "Sync (weekly)" survive.
+	if strings.HasSuffix(title, ")") {
+		if open := strings.LastIndex(title, "("); open >= 0 && parseAttrs(&e, title[open+1:len(title)-1]) {
+			title = strings.TrimSpace(title[:open])
+		}
+	}
+
+	e.Kind = KindNote
+	if title != "" && !strings.EqualFold(title, "note") {
+		e.Title = title
+		for kind, t := range kindTitles {
+			if strings.EqualFold(title, t) {
+				e.Kind = kind
+				e.Title = ""
+				break
+			}
+		}
+	}
+	return e
+}
+
+// parseAttrs reads a comma separated attribute list such as "id: 3f2a9c1d, pinned".
+// It reports false and leaves e untouched if the list has no valid ID.
+func parseAttrs(e *Entry, list string) bool {
+	var attrs Entry
+	for _, attr := range strings.Split(list, ",") {
+		attr = strings.TrimSpace(attr)
+		key, value, hasValue := strings.Cut(attr, ":")
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch {
+		case key == "id" && hasValue && idPattern.MatchString(strings.ToLower(value)):
+			attrs.ID = strings.ToLower(value)
+		case key == "pinned" && !hasValue:
+			attrs.Pinned = true
+		case key == "provider" && hasValue:
+			attrs.Provider = value
+		case key == "author" && hasValue:
+			attrs.Author = value
+		case key == "agent" && hasValue:
+			attrs.Agent = value
+		case key == "expires" && hasValue && validTime(value):
+			attrs.Expires, _ = ParseTime(value)
+		case key == "supersedes" && hasValue && idPattern.MatchString(value):
+			attrs.Supersedes = append(attrs.Supersedes, value)
+		case key == "superseded-by" && hasValue && idPattern.MatchString(value):
+			attrs.SupersededBy = value
+		case attr != "":
+			attrs.Extra = append(attrs.Extra, attr)
+		}
+	}
+	if attrs.ID == "" {
+		return false
+	}
+
+	e.ID = attrs.ID
+	e.Pinned = attrs.Pinned
+	e.Provider = attrs.Provider
+	e.Author = attrs.Author
+	e.Agent = attrs.Agent
+	e.Expires = attrs.Expires
+	e.Supersedes = attrs.Supersedes
+	e.SupersededBy = attrs.SupersededBy
+	e.Extra = attrs.Extra
+	return true
+}
+
+func validTime(value string) bool {
+	_, err := ParseTime(value)
+	return err == nil
+}
@@ -0,0 +1,259 @@
+// Package scratchpad reads and writes the entries stored in .aipad/scratchpad.md.
+//
+// The canonical form of an entry is a level-2 header carrying its metadata,
+// the entry body, and an end marker naming the entry's ID:
+//
+//	## [2026-01-08 23:25:43] Decision (id: 3f2a9c1d, pinned) #api #grpc
+//	We moved service-to-service calls to gRPC.
+//	---
+//	Markdown rules, tables and front matter are safe inside the body.
+//	<!-- aipad:end 3f2a9c1d -->
+//
+// Entries written before the end marker existed are terminated by the next
+// entry header instead, with a trailing "---" separator line dropped.
+package scratchpad
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// TimestampFormat is the layout used for entry timestamps in the scratchpad
+const TimestampFormat = "2006-01-02 15:04:05"
+
+// Entry kinds
+const (
+	KindNote     = "note"
+	KindDecision = "decision"
+	KindTodo     = "todo"
+	KindBug      = "bug"
+)
+
+// Kinds lists the valid entry kinds
+var Kinds = []string{KindDecision, KindTodo, KindBug, KindNote}
+
+// kindTitles maps each kind to the title used in its entry header.
+// Notes keep the original "Context Update" title so older tools still read them.
+var kindTitles = map[string]string{
+	KindNote:     "Context Update",
+	KindDecision: "Decision",
+	KindTodo:     "Todo",
+	KindBug:      "Bug",
+}
+
+// Entry is a single context update in the scratchpad
+type Entry struct {
+	ID        string
+	Timestamp string
+	Kind      string
+	// Title is set when a hand-written header uses a title that is not a kind title
+	Title   string
+	Pinned  bool
+	Tags    []string
+	Content string
+	// Attribution: the provider in use, the user, and the agent (empty for humans)
+	Provider string
+	Author   string
+	Agent    string
+	// Expires is when the entry stops being synced; zero means never
+	Expires time.Time
+	// Supersedes lists the IDs of entries this one replaces
+	Supersedes []string
+	// SupersededBy is the ID of the entry that replaced this one
+	SupersededBy string
+	// Extra holds header attributes this version does not understand, kept verbatim
+	Extra []string
+	// Trailer holds text found after the entry and before the next one
+	Trailer string
+
+	// Byte offsets of the entry within the content it was parsed from
+	start, end int
+}
+
+// Document is a parsed scratchpad
+type Document struct {
+	// Preamble holds any text before the first entry
+	Preamble string
+	Entries  []Entry
+}
+
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_./-]*$`)
+
+// Find returns the entry with the given ID
+func Find(entries []Entry, id string) (Entry, bool) {
+	for _, e := range entries {
+		if e.ID == id {
+			return e, true
+		}
+	}
+	return Entry{}, false
+}
+
+// Format renders an entry in the form it is appended to the scratchpad
+func Format(e Entry) string {
+	return "\n" + block(e) + "\n"
+}
+
+// FormatAll renders entries back to back as they would appear in the scratchpad
+func FormatAll(entries []Entry) string {
+	var b strings.Builder
+	for _, e := range entries {
+		b.WriteString(Format(e))
+	}
+	return b.String()
+}
+
+// String renders the document in canonical form
+func (d *Document) String() string {
+	var b strings.Builder
+	if preamble := strings.TrimSpace(d.Preamble); preamble != "" {
+		b.WriteString(preamble + "\n")
+	}
+	for _, e := range d.Entries {
+		b.WriteString(Format(e))
+		if trailer := strings.TrimSpace(e.Trailer); trailer != "" {
+			b.WriteString("\n" + trailer + "\n")
+		}
+	}
+	return b.String()
+}
+
+func block(e Entry) string {
+	return header(e) + "\n" + e.Content + "\n" + endMarker(e.ID)
+}
+
+func header(e Entry) string {
+	title := e.Title
+	if title == "" {
+		title = kindTitle(e.Kind)
+	}
+
+	attrs := []string{"id: " + e.ID}
+	if e.Pinned {
+		attrs = append(attrs, "pinned")
+	}
+	if e.Provider != "" {
+		attrs = append(attrs, "provider: "+e.Provider)
+	}
+	if e.Author != "" {
+		attrs = append(attrs, "author: "+e.Author)
+	}
+	if e.Agent != "" {
+		attrs = append(attrs, "agent: "+e.Agent)
+	}
+	if !e.Expires.IsZero() {
+		attrs = append(attrs, "expires: "+e.Expires.Format(TimestampFormat))
+	}
+	for _, id := range e.Supersedes {
+		attrs = append(attrs, "supersedes: "+id)
+	}
+	if e.SupersededBy != "" {
+		attrs = append(attrs, "superseded-by: "+e.SupersededBy)
+	}
+	attrs = append(attrs, e.Extra...)
+
+	var tags strings.Builder
+	for _, tag := range e.Tags {
+		tags.WriteString(" #" + tag)
+	}
+	return fmt.Sprintf("## [%s] %s (%s)%s", e.Timestamp, title, strings.Join(attrs, ", "), tags.String())
+}
+
+func endMarker(id string) string {
+	return "<!-- aipad:end " + id + " -->"
+}
+
+// Replace rewrites the entry with the same ID as e in content, leaving the rest untouched
+func Replace(content string, e Entry) (string, error) {
+	old, ok := Find(Parse(content), e.ID)
+	if !ok {
+		return "", fmt.Errorf("entry '%s' not found in scratchpad", e.ID)
+	}
+	return content[:old.start] + block(e) + content[old.end:], nil
+}
+
+// Remove deletes the entry with the given ID from content, leaving the rest untouched
+func Remove(content string, id string) (string, error) {
+	old, ok := Find(Parse(content), id)
+	if !ok {
+		return "", fmt.Errorf("entry '%s' not found in scratchpad", id)
+	}
+	start, end := old.start, old.end
+	// Drop the blank line Format puts before each entry and the newline after it
+	if start > 0 && content[start-1] == '\n' {
+		start--
+	}
+	if end < len(content) && content[end] == '\n' {
+		end++
+	}
+	return content[:start] + content[end:], nil
+}
+
+func kindTitle(kind string) string {
+	if title, ok := kindTitles[kind]; ok {
+		return title
+	}
+	return kindTitles[KindNote]
+}
+
+// ParseKind validates an entry kind, defaulting to a note when empty
+func ParseKind(kind string) (string, error) {
+	kind = strings.ToLower(strings.TrimSpace(kind))
+	if kind == "" {
+		return KindNote, nil
+	}
+	if _, ok := kindTitles[kind]; !ok {
+		return "", fmt.Errorf("invalid kind '%s': must be one of %s", kind, strings.Join(Kinds, ", "))
+	}
+	return kind, nil
+}
+
+// Expired reports whether the entry's TTL has run out at now
+func (e Entry) Expired(now time.Time) bool {
+	return !e.Expires.IsZero() && !now.Before(e.Expires)
+}
+
+// ParseTime reads a local time written as "2006-01-02 15:04:05", "2006-01-02" or RFC 3339
+func ParseTime(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range []string{TimestampFormat, "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time '%s': use YYYY-MM-DD, 'YYYY-MM-DD HH:MM:SS' or RFC 3339", value)
+}
+
+// HasTag reports whether the entry carries the given tag
+func (e Entry) HasTag(tag string) bool {
+	for _, t := range e.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeTags lowercases tags, strips a leading '#', drops duplicates
+// and rejects tags that cannot be rendered in an entry header
+func NormalizeTags(tags []string) ([]string, error) {
+	var result []string
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if !tagPattern.MatchString(tag) {
+			return nil, fmt.Errorf("invalid tag '%s': use letters, digits, '_', '-', '.' or '/'", tag)
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+	return result, nil
+}
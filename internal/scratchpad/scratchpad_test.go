@@ -0,0 +1,306 @@
+package scratchpad
+
+import (
+	"aipad/internal/author"
+	"aipad/internal/crypto"
+	"aipad/internal/state"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sample = `
+## [2026-01-08 23:25:43] Context Update
+The project uses Go and Cobra for CLI development.
+---
+
+## [2026-01-08 23:26:29] Context Update (id: 3f2a9c1d)
+We are also using SHA256 for hashing.
+---
+`
+
+func TestParse(t *testing.T) {
+	entries := Parse(sample)
+	if len(entries) != 2 {
+		t.Fatalf("Parse() returned %d entries, want 2", len(entries))
+	}
+
+	legacyID := state.LegacyEntryID(crypto.GenerateHash("The project uses Go and Cobra for CLI development."))
+	if entries[0].ID != legacyID {
+		t.Errorf("legacy entry ID = %q, want %q", entries[0].ID, legacyID)
+	}
+	if entries[1].ID != "3f2a9c1d" {
+		t.Errorf("entry ID = %q, want %q", entries[1].ID, "3f2a9c1d")
+	}
+	if entries[1].Content != "We are also using SHA256 for hashing." {
+		t.Errorf("entry content = %q", entries[1].Content)
+	}
+}
+
+func TestReplace(t *testing.T) {
+	e, _ := Find(Parse(sample), "3f2a9c1d")
+	e.Content = "We switched to BLAKE3."
+
+	updated, err := Replace(sample, e)
+	if err != nil {
+		t.Fatalf("Replace() error = %v", err)
+	}
+
+	entries := Parse(updated)
+	if len(entries) != 2 {
+		t.Fatalf("Parse() after Replace returned %d entries, want 2", len(entries))
+	}
+	if entries[1].Content != "We switched to BLAKE3." {
+		t.Errorf("replaced content = %q", entries[1].Content)
+	}
+	if entries[0].Content != "The project uses Go and Cobra for CLI development." {
+		t.Errorf("untouched entry changed: %q", entries[0].Content)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	updated, err := Remove(sample, "3f2a9c1d")
+	if err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+
+	entries := Parse(updated)
+	if len(entries) != 1 {
+		t.Fatalf("Parse() after Remove returned %d entries, want 1", len(entries))
+	}
+
+	if _, err := Remove(sample, "deadbeef"); err == nil {
+		t.Error("Remove() of unknown ID should fail")
+	}
+}
+
+func TestFilter(t *testing.T) {
+	entries := Parse(`
+## [2026-01-08 23:25:43] Context Update (id: 00000001) #auth #db
+Sessions live in Postgres.
+---
+
+## [2026-01-08 23:26:29] Context Update (id: 00000002) #debug
+Flaky login test on CI.
+---
+
+## [2026-01-08 23:27:00] Context Update (id: 00000003)
+Use pnpm, not npm.
+---
+`)
+	if len(entries[0].Tags) != 2 || entries[0].Tags[1] != "db" {
+		t.Fatalf("parsed tags = %v, want [auth db]", entries[0].Tags)
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   int
+	}{
+		{"empty", Filter{}, 3},
+		{"include", Filter{Tags: []string{"db"}}, 1},
+		{"include any", Filter{Tags: []string{"db", "debug"}}, 2},
+		{"exclude", Filter{ExcludeTags: []string{"debug"}}, 2},
+		{"include and exclude", Filter{Tags: []string{"auth"}, ExcludeTags: []string{"db"}}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := len(tt.filter.Select(entries)); got != tt.want {
+				t.Errorf("Select() returned %d entries, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeTags(t *testing.T) {
+	tags, err := NormalizeTags([]string{"#Auth", "db", "auth", " "})
+	if err != nil {
+		t.Fatalf("NormalizeTags() error = %v", err)
+	}
+	if len(tags) != 2 || tags[0] != "auth" || tags[1] != "db" {
+		t.Errorf("NormalizeTags() = %v, want [auth db]", tags)
+	}
+
+	if _, err := NormalizeTags([]string{"bad tag"}); err == nil {
+		t.Error("NormalizeTags() should reject tags with spaces")
+	}
+}
+
+func TestKindRoundTrip(t *testing.T) {
+	for _, kind := range Kinds {
+		t.Run(kind, func(t *testing.T) {
+			e := Entry{ID: "0000000a", Timestamp: "2026-01-08 23:25:43", Kind: kind, Content: "text"}
+			entries := Parse(Format(e))
+			if len(entries) != 1 || entries[0].Kind != kind {
+				t.Errorf("Parse(Format()) = %+v, want kind %q", entries, kind)
+			}
+		})
+	}
+
+	if _, err := ParseKind("idea"); err == nil {
+		t.Error("ParseKind() should reject unknown kinds")
+	}
+}
+
+func TestPinnedRoundTrip(t *testing.T) {
+	e := Entry{ID: "0000000b", Timestamp: "2026-01-08 23:25:43", Kind: KindDecision, Pinned: true, Tags: []string{"billing"}, Content: "Never touch the legacy billing module."}
+	entries := Parse(Format(e))
+	if len(entries) != 1 {
+		t.Fatalf("Parse(Format()) returned %d entries, want 1", len(entries))
+	}
+	if !entries[0].Pinned || entries[0].ID != e.ID || !entries[0].HasTag("billing") {
+		t.Errorf("Parse(Format()) = %+v, want pinned entry %q with tag billing", entries[0], e.ID)
+	}
+}
+
+func TestParseBodyWithRules(t *testing.T) {
+	body := "---\ntitle: front matter\n---\n\n| a | b |\n|---|---|\n| 1 | 2 |\n\n---\nAfter the rule."
+	e := Entry{ID: "0000000c", Timestamp: "2026-01-08 23:25:43", Kind: KindNote, Content: body}
+	content := Format(e) + Format(Entry{ID: "0000000d", Timestamp: "2026-01-08 23:26:00", Kind: KindNote, Content: "Next."})
+
+	entries := Parse(content)
+	if len(entries) != 2 {
+		t.Fatalf("Parse() returned %d entries, want 2", len(entries))
+	}
+	if entries[0].Content != body {
+		t.Errorf("body = %q, want %q", entries[0].Content, body)
+	}
+}
+
+func TestParseLegacyBodyWithRule(t *testing.T) {
+	content := "\n## [2026-01-08 23:25:43] Context Update\nBefore\n---\nAfter\n---\n\n## [2026-01-08 23:26:29] Context Update\nNext\n---\n"
+	entries := Parse(content)
+	if len(entries) != 2 {
+		t.Fatalf("Parse() returned %d entries, want 2", len(entries))
+	}
+	if entries[0].Content != "Before\n---\nAfter" {
+		t.Errorf("legacy body = %q, want %q", entries[0].Content, "Before\n---\nAfter")
+	}
+}
+
+func TestParseHandEditedHeader(t *testing.T) {
+	entries := Parse("##  [2026-01-10]   weekly sync (notes) #Team\nDiscussed roadmap.\n")
+	if len(entries) != 1 {
+		t.Fatalf("Parse() returned %d entries, want 1", len(entries))
+	}
+	e := entries[0]
+	if e.Title != "weekly sync (notes)" || e.Kind != KindNote || !e.HasTag("team") || e.Timestamp != "2026-01-10" {
+		t.Errorf("Parse() = %+v", e)
+	}
+
+	entries = Parse("## [2026-01-10 10:00:00] decision (id: 0000000E, pinned, owner: ana)\nUse pnpm.\n<!-- aipad:end 0000000e -->\n")
+	e = entries[0]
+	if e.Kind != KindDecision || e.ID != "0000000e" || !e.Pinned || len(e.Extra) != 1 || e.Extra[0] != "owner: ana" {
+		t.Errorf("Parse() = %+v", e)
+	}
+}
+
+func TestDocumentRoundTrip(t *testing.T) {
+	content := "# Project notes\n\nKept as preamble.\n" + sample + "\nStray text after the last entry.\n"
+
+	doc := ParseDocument(content)
+	canonical := doc.String()
+
+	for _, want := range []string{"# Project notes", "Kept as preamble.", "Stray text after the last entry."} {
+		if !strings.Contains(canonical, want) {
+			t.Errorf("canonical form lost %q:\n%s", want, canonical)
+		}
+	}
+
+	// Canonical form is a fixed point
+	if again := ParseDocument(canonical).String(); again != canonical {
+		t.Errorf("String() is not stable:\n%s\n---- vs ----\n%s", canonical, again)
+	}
+
+	// Entries survive unchanged
+	before, after := doc.Entries, Parse(canonical)
+	if len(before) != len(after) {
+		t.Fatalf("entry count changed: %d -> %d", len(before), len(after))
+	}
+	for i := range before {
+		if before[i].ID != after[i].ID || before[i].Content != after[i].Content {
+			t.Errorf("entry %d changed: %+v -> %+v", i, before[i], after[i])
+		}
+	}
+}
+
+func TestFilterAttribution(t *testing.T) {
+	entries := []Entry{
+		{ID: "1", Provider: "claude", Author: "ana", Agent: "claude-code"},
+		{ID: "2", Provider: "antigravity", Author: "ana"},
+		{ID: "3", Provider: "antigravity", Author: "budi", Agent: "antigravity"},
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   int
+	}{
+		{"provider", Filter{Providers: []string{"antigravity"}}, 2},
+		{"author", Filter{Authors: []string{"ANA"}}, 2},
+		{"agents", Filter{Source: "agent"}, 2},
+		{"humans", Filter{Source: "human"}, 1},
+		{"combined", Filter{Providers: []string{"antigravity"}, Source: "agent"}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := len(tt.filter.Select(entries)); got != tt.want {
+				t.Errorf("Select() returned %d entries, want %d", got, tt.want)
+			}
+		})
+	}
+
+	e := Entry{ID: "0000000f", Timestamp: "2026-01-08 23:25:43", Kind: KindNote, Provider: "claude", Author: "Ana Putri", Agent: "claude-code", Content: "x"}
+	parsed := Parse(Format(e))[0]
+	if parsed.Provider != e.Provider || parsed.Author != e.Author || parsed.Agent != e.Agent {
+		t.Errorf("attribution did not round-trip: %+v", parsed)
+	}
+
+	// Detected values that look like tags or markers are sanitized first
+	e = Entry{ID: "0000000f", Timestamp: "2026-01-08 23:25:43", Kind: KindNote, Author: author.Sanitize("ci #nightly"), Agent: author.Sanitize("bot <!-- aipad:end --> #x"), Content: "x"}
+	parsed = Parse(Format(e))[0]
+	if parsed.Author != "ci nightly" || parsed.Agent != "bot !-- aipad:end -- x" || len(parsed.Tags) != 0 {
+		t.Errorf("sanitized attribution did not round-trip: %+v", parsed)
+	}
+}
+
+func TestExpires(t *testing.T) {
+	expires, err := ParseTime("2026-11-01")
+	if err != nil {
+		t.Fatalf("ParseTime() error = %v", err)
+	}
+
+	e := Entry{ID: "00000010", Timestamp: "2026-10-28 09:00:00", Kind: KindNote, Expires: expires, Content: "Staging DB is down."}
+	parsed := Parse(Format(e))[0]
+	if !parsed.Expires.Equal(expires) {
+		t.Fatalf("Expires = %v, want %v", parsed.Expires, expires)
+	}
+
+	if parsed.Expired(expires.Add(-time.Second)) {
+		t.Error("entry should not be expired before its expiry time")
+	}
+	if !parsed.Expired(expires) {
+		t.Error("entry should be expired at its expiry time")
+	}
+	if (Entry{}).Expired(expires) {
+		t.Error("entry without expiry should never expire")
+	}
+}
+
+func TestSupersedesRoundTrip(t *testing.T) {
+	e := Entry{ID: "00000012", Timestamp: "2026-01-08 23:25:43", Kind: KindDecision, Supersedes: []string{"00000010", "00000011"}, Content: "We moved to gRPC."}
+	old := Entry{ID: "00000010", Timestamp: "2026-01-01 10:00:00", Kind: KindDecision, SupersededBy: "00000012", Content: "We use REST."}
+
+	entries := Parse(Format(old) + Format(e))
+	if len(entries) != 2 {
+		t.Fatalf("Parse() returned %d entries, want 2", len(entries))
+	}
+	if entries[0].SupersededBy != "00000012" {
+		t.Errorf("SupersededBy = %q, want %q", entries[0].SupersededBy, "00000012")
+	}
+	if len(entries[1].Supersedes) != 2 || entries[1].Supersedes[1] != "00000011" {
+		t.Errorf("Supersedes = %v, want [00000010 00000011]", entries[1].Supersedes)
+	}
+}
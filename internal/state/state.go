@@ -3,8 +3,11 @@ package state
 import (
 	"aipad/internal/config"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,6 +17,9 @@ const (
 	StateType      = "state.json"
 	AIPadDir       = ".aipad"
 	ScratchpadFile = "scratchpad.md"
+
+	// EntryIDLength is the number of hex characters in an entry ID
+	EntryIDLength = 8
 )
 
 type ProviderConfig struct {
@@ -21,6 +27,25 @@ type ProviderConfig struct {
 	RulesDir   string `json:"rules_dir"`
 }
 
+// Entry holds the metadata tracked for a single scratchpad entry.
+// Entries is index-aligned with ContextHashes and ContextHistory.
+type Entry struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Kind      string    `json:"kind,omitempty"`
+	Pinned    bool      `json:"pinned,omitempty"`
+	Tags      []string  `json:"tags,omitempty"`
+	Provider  string    `json:"provider,omitempty"`
+	Author    string    `json:"author,omitempty"`
+	Agent     string    `json:"agent,omitempty"`
+	// ExpiresAt is when the entry stops being synced; nil means never
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// Supersedes lists the IDs of entries this one replaces
+	Supersedes []string `json:"supersedes,omitempty"`
+	// SupersededBy is the ID of the entry that replaced this one
+	SupersededBy string `json:"superseded_by,omitempty"`
+}
+
 type State struct {
 	Version         string                    `json:"version"`
 	CurrentProvider string                    `json:"current_provider"`
@@ -29,6 +54,7 @@ type State struct {
 	LastSync        time.Time                 `json:"last_sync"`
 	ContextHashes   []string                  `json:"context_hashes"`
 	ContextHistory  []string                  `json:"context_history"`
+	Entries         []Entry                   `json:"entries"`
 	Providers       map[string]ProviderConfig `json:"providers"`
 }
 
@@ -41,6 +67,15 @@ func GetStatePath() (string, error) {
 	return filepath.Join(cwd, AIPadDir, StateType), nil
 }
 
+// GetScratchpadPath returns the path to the scratchpad.md file
+func GetScratchpadPath() (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cwd, AIPadDir, ScratchpadFile), nil
+}
+
 // InitAIPadDir creates the .aipad directory if it doesn't exist
 func InitAIPadDir() error {
 	cwd, err := os.Getwd()
@@ -102,6 +137,21 @@ func getAllProviders() map[string]ProviderConfig {
 	return providers
 }
 
+// EnabledProviders returns the names of the providers in s that are builtin
+// or enabled in providers.json, sorted. Custom providers that were disabled or
+// removed since the session started are left out.
+func (s *State) EnabledProviders() []string {
+	enabled := getAllProviders()
+	var names []string
+	for name := range s.Providers {
+		if _, ok := enabled[name]; ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
 // NewState creates a default state object
 func NewState(provider string) *State {
 	return &State{
@@ -112,6 +162,7 @@ func NewState(provider string) *State {
 		LastSync:        time.Now(),
 		ContextHashes:   []string{},
 		ContextHistory:  []string{},
+		Entries:         []Entry{},
 		Providers:       getAllProviders(),
 	}
 }
@@ -123,7 +174,7 @@ func (s *State) Save() error {
 		return err
 	}
 
-	data, err := json.MarshalIndent(s, "", "  ")
+	data, err := s.Marshal()
 	if err != nil {
 		return err
 	}
@@ -131,6 +182,11 @@ func (s *State) Save() error {
 	return os.WriteFile(path, data, 0644)
 }
 
+// Marshal returns the state as Save writes it
+func (s *State) Marshal() ([]byte, error) {
+	return json.MarshalIndent(s, "", "  ")
+}
+
 // Load reads the state from disk
 func Load() (*State, error) {
 	path, err := GetStatePath()
@@ -168,5 +224,93 @@ func Load() (*State, error) {
 		}
 	}
 
+	// Backward compatibility: entries recorded before IDs existed get an ID
+	// derived from their content hash, matching what the scratchpad parser assigns
+	for i := len(s.Entries); i < len(s.ContextHashes); i++ {
+		s.Entries = append(s.Entries, Entry{ID: LegacyEntryID(s.ContextHashes[i])})
+	}
+
 	return &s, nil
 }
+
+// LegacyEntryID derives the ID of an entry that was written without one
+func LegacyEntryID(hash string) string {
+	if len(hash) < EntryIDLength {
+		return hash
+	}
+	return hash[:EntryIDLength]
+}
+
+// newEntryID generates a short random ID that is unique within the state
+func (s *State) newEntryID() string {
+	for {
+		id := strings.ReplaceAll(uuid.New().String(), "-", "")[:EntryIDLength]
+		if _, err := s.FindEntry(id); err != nil {
+			return id
+		}
+	}
+}
+
+// AddEntry records a new entry and returns its metadata.
+// The returned pointer is valid until the next entry is added or removed.
+func (s *State) AddEntry(text, hash string) *Entry {
+	entry := Entry{
+		ID:        s.newEntryID(),
+		CreatedAt: time.Now(),
+	}
+	s.ContextHashes = append(s.ContextHashes, hash)
+	s.ContextHistory = append(s.ContextHistory, text) // Store full text for fuzzy matching
+	s.Entries = append(s.Entries, entry)
+	return &s.Entries[len(s.Entries)-1]
+}
+
+// EntryIndex returns the index of the entry with exactly the given ID, or -1
+func (s *State) EntryIndex(id string) int {
+	for i, e := range s.Entries {
+		if e.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// FindEntry returns the index of the entry with the given ID.
+// A unique prefix of an ID is also accepted.
+func (s *State) FindEntry(id string) (int, error) {
+	if id == "" {
+		return -1, fmt.Errorf("entry ID cannot be empty")
+	}
+	found := -1
+	for i, e := range s.Entries {
+		if e.ID == id {
+			return i, nil
+		}
+		if strings.HasPrefix(e.ID, id) {
+			if found >= 0 {
+				return -1, fmt.Errorf("entry ID '%s' is ambiguous", id)
+			}
+			found = i
+		}
+	}
+	if found < 0 {
+		return -1, fmt.Errorf("entry '%s' not found", id)
+	}
+	return found, nil
+}
+
+// UpdateEntry replaces the text of the entry at index i
+func (s *State) UpdateEntry(i int, text, hash string) {
+	s.ContextHashes[i] = hash
+	if i < len(s.ContextHistory) {
+		s.ContextHistory[i] = text
+	}
+}
+
+// RemoveEntry deletes the entry at index i from all index-aligned records
+func (s *State) RemoveEntry(i int) {
+	s.ContextHashes = append(s.ContextHashes[:i], s.ContextHashes[i+1:]...)
+	if i < len(s.ContextHistory) {
+		s.ContextHistory = append(s.ContextHistory[:i], s.ContextHistory[i+1:]...)
+	}
+	s.Entries = append(s.Entries[:i], s.Entries[i+1:]...)
+}
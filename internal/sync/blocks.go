@@ -0,0 +1,159 @@
+package sync
+
+import (
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Managed block names. Each block is rendered on its own and can be moved
+// anywhere in a config file.
+const (
+	BlockInstructions = "instructions"
+	BlockTodos        = "todos"
+	BlockContext      = "context"
+)
+
+// BlockNames lists the managed blocks in the order missing ones are appended to a config file
+var BlockNames = []string{BlockInstructions, BlockTodos, BlockContext}
+
+// blockStartPattern matches the start marker of a named block, e.g. <!-- AIPAD:context -->
+var blockStartPattern = regexp.MustCompile(`<!-- AIPAD:(` + strings.Join(BlockNames, "|") + `) -->`)
+
+// BlockStart returns the marker that opens the named block
+func BlockStart(name string) string {
+	return "<!-- AIPAD:" + name + " -->"
+}
+
+// BlockEnd returns the marker that closes the named block
+func BlockEnd(name string) string {
+	return "<!-- /AIPAD:" + name + " -->"
+}
+
+// blockSpan is a managed block found in a config file
+type blockSpan struct {
+	// Name is the block name, or empty for a legacy MarkerStart/MarkerEnd block
+	Name string
+	// Start and End delimit the block in the file, markers included
+	Start, End int
+	// Content is the text between the markers
+	Content string
+}
+
+// findBlocks returns the managed blocks of a config file in file order. A
+// start marker without an end marker before the next block starts is ignored.
+func findBlocks(content string) []blockSpan {
+	var spans []blockSpan
+	pos := 0
+	for {
+		name, start, open := nextStart(content[pos:])
+		if start < 0 {
+			return spans
+		}
+		start += pos
+
+		closing := MarkerEnd
+		if name != "" {
+			closing = BlockEnd(name)
+		}
+		inner := start + len(open)
+		end := strings.Index(content[inner:], closing)
+		if _, next, _ := nextStart(content[inner:]); end < 0 || (next >= 0 && next < end) {
+			pos = inner
+			continue
+		}
+		end += inner
+
+		text := strings.TrimSuffix(strings.TrimPrefix(content[inner:end], "\n"), "\n")
+		spans = append(spans, blockSpan{Name: name, Start: start, End: end + len(closing), Content: text})
+		pos = end + len(closing)
+	}
+}
+
+// nextStart finds the earliest start marker in content, named or legacy, and
+// returns the block name, the marker's index and the marker. The index is -1
+// if there is none.
+func nextStart(content string) (string, int, string) {
+	name, start, open := "", strings.Index(content, MarkerStart), MarkerStart
+	if loc := blockStartPattern.FindStringSubmatchIndex(content); loc != nil && (start < 0 || loc[0] < start) {
+		name, start = content[loc[2]:loc[3]], loc[0]
+		open = BlockStart(name)
+	}
+	return name, start, open
+}
+
+// renderBlock wraps content in the named block's markers
+func renderBlock(name, content string) string {
+	return BlockStart(name) + "\n" + content + "\n" + BlockEnd(name)
+}
+
+// blockKey is the ledger key of a named block in a config file
+func blockKey(configPath, name string) string {
+	return configPath + "#" + name
+}
+
+// UpdateConfigBlocks writes the content of each managed block into the config
+// file. Blocks are replaced where they are, so they can be moved around the
+// file; blocks the file lacks are appended in BlockNames order. A legacy
+// single block is replaced by the named blocks in place. Blocks missing from
+// blocks are written empty.
+func UpdateConfigBlocks(configPath string, blocks map[string]string) error {
+	existingContent, err := os.ReadFile(configPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	content := string(existingContent)
+
+	spans := findBlocks(content)
+	present := make(map[string]bool)
+	for _, span := range spans {
+		if span.Name != "" {
+			present[span.Name] = true
+		}
+	}
+
+	var b strings.Builder
+	pos := 0
+	migrated := false
+	for _, span := range spans {
+		b.WriteString(content[pos:span.Start])
+		pos = span.End
+		if span.Name != "" {
+			b.WriteString(renderBlock(span.Name, blocks[span.Name]))
+			continue
+		}
+		if migrated {
+			// Only the first legacy block is migrated; others are dropped
+			continue
+		}
+		// The legacy block becomes the named blocks not placed elsewhere
+		var named []string
+		for _, name := range BlockNames {
+			if !present[name] {
+				named = append(named, renderBlock(name, blocks[name]))
+				present[name] = true
+			}
+		}
+		b.WriteString(strings.Join(named, "\n\n"))
+		migrated = true
+	}
+	b.WriteString(content[pos:])
+
+	for _, name := range BlockNames {
+		if present[name] {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(renderBlock(name, blocks[name]) + "\n")
+	}
+
+	if err := os.WriteFile(configPath, []byte(b.String()), 0644); err != nil {
+		return err
+	}
+	for _, name := range BlockNames {
+		recordWrite(blockKey(configPath, name), blocks[name])
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package sync
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestUpdateConfigBlocks(t *testing.T) {
+	blocks := map[string]string{
+		BlockInstructions: "read this",
+		BlockTodos:        "- [ ] task",
+		BlockContext:      "context",
+	}
+	instructions := renderBlock(BlockInstructions, "read this")
+	todos := renderBlock(BlockTodos, "- [ ] task")
+	context := renderBlock(BlockContext, "context")
+
+	tests := []struct {
+		name     string
+		existing string
+		expected string
+	}{
+		{
+			name:     "new file",
+			expected: instructions + "\n\n" + todos + "\n\n" + context + "\n",
+		},
+		{
+			name:     "missing blocks are appended in order",
+			existing: "# Rules\n",
+			expected: "# Rules\n\n" + instructions + "\n\n" + todos + "\n\n" + context + "\n",
+		},
+		{
+			name:     "blocks are updated where they are",
+			existing: "# Rules\n\n" + BlockStart(BlockContext) + "\nold\n" + BlockEnd(BlockContext) + "\n\n- Use pnpm\n\n" + BlockStart(BlockTodos) + "\n\n" + BlockEnd(BlockTodos) + "\n",
+			expected: "# Rules\n\n" + context + "\n\n- Use pnpm\n\n" + todos + "\n\n" + instructions + "\n",
+		},
+		{
+			name:     "legacy block is migrated in place",
+			existing: "# Rules\n\n" + MarkerStart + "\nold\n" + MarkerEnd + "\n\n- Use pnpm\n",
+			expected: "# Rules\n\n" + instructions + "\n\n" + todos + "\n\n" + context + "\n\n- Use pnpm\n",
+		},
+		{
+			name:     "legacy block keeps named blocks placed elsewhere",
+			existing: BlockStart(BlockContext) + "\n" + BlockEnd(BlockContext) + "\n" + MarkerStart + "\nold\n" + MarkerEnd + "\n",
+			expected: context + "\n" + instructions + "\n\n" + todos + "\n",
+		},
+		{
+			name:     "unterminated block is left alone",
+			existing: BlockStart(BlockContext) + "\n- Use pnpm\n",
+			expected: BlockStart(BlockContext) + "\n- Use pnpm\n\n" + instructions + "\n\n" + todos + "\n\n" + context + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "CLAUDE.md")
+			if tt.existing != "" {
+				if err := os.WriteFile(path, []byte(tt.existing), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := UpdateConfigBlocks(path, blocks); err != nil {
+				t.Fatalf("UpdateConfigBlocks() error = %v", err)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.expected {
+				t.Errorf("UpdateConfigBlocks() wrote\n%s\nexpected\n%s", data, tt.expected)
+			}
+
+			// Syncing again changes nothing
+			if err := UpdateConfigBlocks(path, blocks); err != nil {
+				t.Fatal(err)
+			}
+			again, _ := os.ReadFile(path)
+			if string(again) != string(data) {
+				t.Errorf("second UpdateConfigBlocks() wrote\n%s", again)
+			}
+		})
+	}
+}
+
+func TestFindBlocks(t *testing.T) {
+	content := "a\n" + renderBlock(BlockTodos, "x\ny") + "\nb\n" + MarkerStart + "\nlegacy\n" + MarkerEnd + "\n<!-- AIPAD:other -->\n"
+	var got []blockSpan
+	for _, span := range findBlocks(content) {
+		span.Start, span.End = 0, 0
+		got = append(got, span)
+	}
+	expected := []blockSpan{{Name: BlockTodos, Content: "x\ny"}, {Content: "legacy"}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("findBlocks() = %+v, expected %+v", got, expected)
+	}
+}
@@ -0,0 +1,254 @@
+package sync
+
+import (
+	"aipad/internal/config"
+	"aipad/internal/diff"
+	"aipad/internal/scratchpad"
+	"aipad/internal/state"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Drift is a synced file that was edited since aipad last wrote it
+type Drift struct {
+	// Path is the edited file, relative to the project root
+	Path string
+	// Rules is set for a rules directory copy of the scratchpad and unset for a managed block
+	Rules bool
+	// Block names the edited managed block; it is empty for a legacy block or a rules copy
+	Block string
+	// Base is what aipad last wrote; Current is what the file holds now
+	Base    string
+	Current string
+}
+
+// String names the edited file, and the block for a named managed block
+func (d *Drift) String() string {
+	if d.Block != "" {
+		return fmt.Sprintf("%s (%s block)", d.Path, d.Block)
+	}
+	return d.Path
+}
+
+// BlockDrift returns the drift of each managed block in configPath that holds
+// something aipad did not write. A block repeated with the same edits is
+// returned once.
+func BlockDrift(ledger Ledger, configPath string) ([]*Drift, error) {
+	content, err := os.ReadFile(configPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var drifts []*Drift
+	for _, span := range findBlocks(string(content)) {
+		key := configPath
+		if span.Name != "" {
+			key = blockKey(configPath, span.Name)
+		}
+		if ledger.Written(key, span.Content) {
+			continue
+		}
+		d := &Drift{Path: ledgerKey(configPath), Block: span.Name, Current: span.Content}
+		if slices.ContainsFunc(drifts, func(other *Drift) bool { return other.Block == d.Block && other.Current == d.Current }) {
+			continue
+		}
+		d.Base, _ = ledger.Base(key)
+		drifts = append(drifts, d)
+	}
+	return drifts, nil
+}
+
+// RulesDrift returns the drift of the scratchpad copy in a rules directory,
+// or nil if it is missing, a link, or holds something aipad wrote. Links
+// cannot drift: editing them edits the scratchpad itself.
+func RulesDrift(ledger Ledger, scratchpadPath, rulesDir string) (*Drift, error) {
+	link, err := InspectRulesLink(scratchpadPath, rulesDir)
+	if err != nil || link.Mode != config.LinkCopy {
+		return nil, err
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(cwd, link.Path)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if ledger.Written(path, string(content)) {
+		return nil, nil
+	}
+	base, _ := ledger.Base(path)
+	return &Drift{Path: link.Path, Rules: true, Base: base, Current: string(content)}, nil
+}
+
+// Fold is the result of folding the edits made to a managed block back into the scratchpad
+type Fold struct {
+	// Scratchpad is the updated scratchpad content
+	Scratchpad string
+	// Edited holds the IDs of entries whose edits were merged
+	Edited []string
+	// Added is the ID of the entry holding text added outside any entry, if any
+	Added string
+	// Done holds the IDs of tasks ticked off in the Open Tasks checklist
+	Done []string
+	// Removed holds the IDs of entries deleted from the block; they stay in the scratchpad
+	Removed []string
+}
+
+var (
+	blockEntryPattern   = regexp.MustCompile(`^#### \[[^\]]*\] \(id: ([0-9A-Za-z]+)\)`)
+	blockHeadingPattern = regexp.MustCompile(`^#{1,4} `)
+	blockTaskPattern    = regexp.MustCompile(`^- \[([ xX])\] .*\(id: ([0-9A-Za-z]+)`)
+)
+
+// parsedBlock is a managed block split into the parts aipad renders
+type parsedBlock struct {
+	// ids lists entry IDs in order; contents maps them to their text
+	ids      []string
+	contents map[string]string
+	// ticked maps task IDs to whether their checkbox is ticked
+	ticked map[string]bool
+	// owned marks the lines that belong to an entry, a task or a heading
+	owned []bool
+}
+
+func parseBlock(block string) parsedBlock {
+	p := parsedBlock{contents: make(map[string]string), ticked: make(map[string]bool)}
+	lines := strings.Split(block, "\n")
+	p.owned = make([]bool, len(lines))
+
+	var id string
+	var content []string
+	flush := func() {
+		if id != "" {
+			p.ids = append(p.ids, id)
+			p.contents[id] = strings.TrimSpace(strings.Join(content, "\n"))
+		}
+		id, content = "", nil
+	}
+	for i, line := range lines {
+		switch {
+		case blockEntryPattern.MatchString(line):
+			flush()
+			id = blockEntryPattern.FindStringSubmatch(line)[1]
+			p.owned[i] = true
+		case blockHeadingPattern.MatchString(line):
+			flush()
+			p.owned[i] = true
+		case id != "":
+			content = append(content, line)
+			p.owned[i] = true
+		case blockTaskPattern.MatchString(line):
+			m := blockTaskPattern.FindStringSubmatch(line)
+			p.ticked[m[2]] = m[1] != " "
+			p.owned[i] = true
+		}
+	}
+	flush()
+	return p
+}
+
+// FoldBlock merges the edits made to a managed block since aipad wrote base
+// into the scratchpad: edited entries are merged three-way with their
+// scratchpad version, text added outside any entry becomes a new entry, and
+// ticked tasks are reported in Done. It fails without changing anything if an
+// entry was edited differently in the block and in the scratchpad.
+func FoldBlock(base, current, scratchpadContent string) (Fold, error) {
+	fold := Fold{Scratchpad: scratchpadContent}
+	was, now := parseBlock(base), parseBlock(current)
+	entries := scratchpad.Parse(scratchpadContent)
+
+	var added []string
+	for _, id := range now.ids {
+		edited := now.contents[id]
+		original, ok := was.contents[id]
+		if !ok {
+			// An entry aipad never wrote: keep its text as new content
+			added = append(added, edited)
+			continue
+		}
+		if edited == original {
+			continue
+		}
+
+		e, ok := scratchpad.Find(entries, id)
+		if !ok {
+			added = append(added, edited)
+			continue
+		}
+		ours := strings.TrimSpace(e.Content)
+		switch ours {
+		case original:
+			e.Content = edited
+		case edited:
+			continue
+		default:
+			merged, ok := diff.Merge3(original+"\n", ours+"\n", edited+"\n")
+			if !ok {
+				return Fold{}, fmt.Errorf("entry %s was edited both in the managed block and in the scratchpad", id)
+			}
+			e.Content = strings.TrimSuffix(merged, "\n")
+		}
+		updated, err := scratchpad.Replace(fold.Scratchpad, e)
+		if err != nil {
+			return Fold{}, err
+		}
+		fold.Scratchpad = updated
+		fold.Edited = append(fold.Edited, id)
+	}
+
+	for _, id := range was.ids {
+		if _, ok := now.contents[id]; !ok {
+			fold.Removed = append(fold.Removed, id)
+		}
+	}
+	for id, ticked := range now.ticked {
+		if ticked && !was.ticked[id] {
+			fold.Done = append(fold.Done, id)
+		}
+	}
+	slices.Sort(fold.Done)
+
+	// Lines added outside entries, tasks and headings
+	var loose []string
+	line := 0
+	for _, c := range diff.Lines(base, current) {
+		for _, text := range c.Words {
+			if c.Op == diff.Delete {
+				continue
+			}
+			if c.Op == diff.Insert && !now.owned[line] {
+				loose = append(loose, text)
+			}
+			line++
+		}
+	}
+	if text := strings.TrimSpace(strings.Join(loose, "\n")); text != "" {
+		added = append(added, text)
+	}
+
+	if len(added) > 0 {
+		content := strings.Join(added, "\n\n")
+		sum := sha256.Sum256([]byte(content))
+		e := scratchpad.Entry{
+			ID:        state.LegacyEntryID(hex.EncodeToString(sum[:])),
+			Timestamp: time.Now().Format(scratchpad.TimestampFormat),
+			Kind:      scratchpad.KindNote,
+			Content:   content,
+		}
+		fold.Scratchpad += scratchpad.Format(e)
+		fold.Added = e.ID
+	}
+	return fold, nil
+}
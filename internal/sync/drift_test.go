@@ -0,0 +1,198 @@
+package sync
+
+import (
+	"aipad/internal/journal"
+	"aipad/internal/scratchpad"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFoldBlock(t *testing.T) {
+	entries := []scratchpad.Entry{
+		{ID: "aaaa0001", Timestamp: "2026-01-01 10:00:00", Kind: scratchpad.KindNote, Content: "Sessions are stored in Postgres 15."},
+		{ID: "aaaa0002", Timestamp: "2026-01-01 11:00:00", Kind: scratchpad.KindNote, Content: "Deploys go through CI."},
+	}
+	pad := scratchpad.FormatAll(entries)
+	base := "## Current Session Context\n\n" +
+		"### Open Tasks\n\n- [ ] Migrate the session table (id: t1)\n\n" +
+		RenderContext(scratchpad.Parse(pad))
+
+	tests := []struct {
+		name     string
+		current  string
+		pad      string
+		expected Fold
+		contents map[string]string
+		added    string
+		err      bool
+	}{
+		{
+			name:     "unchanged",
+			current:  base,
+			pad:      pad,
+			expected: Fold{},
+		},
+		{
+			name:     "edited entry",
+			current:  strings.Replace(base, "Postgres 15", "Postgres 16", 1),
+			pad:      pad,
+			expected: Fold{Edited: []string{"aaaa0001"}},
+			contents: map[string]string{"aaaa0001": "Sessions are stored in Postgres 16."},
+		},
+		{
+			name:     "edited in both places",
+			current:  strings.Replace(base, "Deploys go through CI.", "Deploys go through CI.\nReleases are tagged.", 1),
+			pad:      strings.Replace(pad, "Deploys go through CI.", "Hotfixes skip staging.\nDeploys go through CI.", 1),
+			expected: Fold{Edited: []string{"aaaa0002"}},
+			contents: map[string]string{"aaaa0002": "Hotfixes skip staging.\nDeploys go through CI.\nReleases are tagged."},
+		},
+		{
+			name:    "conflicting edits",
+			current: strings.Replace(base, "Postgres 15", "Postgres 16", 1),
+			pad:     strings.Replace(pad, "Postgres 15", "MySQL 8", 1),
+			err:     true,
+		},
+		{
+			name:     "ticked task",
+			current:  strings.Replace(base, "- [ ] Migrate", "- [x] Migrate", 1),
+			pad:      pad,
+			expected: Fold{Done: []string{"t1"}},
+		},
+		{
+			name:     "added text",
+			current:  strings.Replace(base, "## Current Session Context\n", "## Current Session Context\n\nDeploys are frozen on Fridays.\n", 1),
+			pad:      pad,
+			expected: Fold{Added: "new"},
+			added:    "Deploys are frozen on Fridays.",
+		},
+		{
+			name:     "removed entry",
+			current:  strings.Replace(base, RenderContext(scratchpad.Parse(pad)), RenderContext(scratchpad.Parse(pad)[:1]), 1),
+			pad:      pad,
+			expected: Fold{Removed: []string{"aaaa0002"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fold, err := FoldBlock(base, tt.current, tt.pad)
+			if (err != nil) != tt.err {
+				t.Fatalf("FoldBlock() error = %v, expected error %v", err, tt.err)
+			}
+			if tt.err {
+				return
+			}
+
+			folded := scratchpad.Parse(fold.Scratchpad)
+			if tt.expected.Added != "" {
+				if fold.Added == "" {
+					t.Fatalf("FoldBlock() added no entry")
+				}
+				e, ok := scratchpad.Find(folded, fold.Added)
+				if !ok || e.Content != tt.added {
+					t.Errorf("added entry = %+v, expected content %q", e, tt.added)
+				}
+				tt.expected.Added = fold.Added
+			}
+			for id, content := range tt.contents {
+				if e, _ := scratchpad.Find(folded, id); e.Content != content {
+					t.Errorf("entry %s = %q, expected %q", id, e.Content, content)
+				}
+			}
+			fold.Scratchpad = ""
+			if !reflect.DeepEqual(fold, tt.expected) {
+				t.Errorf("FoldBlock() = %+v, expected %+v", fold, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBlockDrift(t *testing.T) {
+	dir := chdirTemp(t)
+	os.Mkdir(".aipad", 0755)
+	configPath := filepath.Join(dir, "CLAUDE.md")
+	read := func() string {
+		data, err := os.ReadFile(configPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	// Nothing recorded yet: no drift can be detected
+	os.WriteFile(configPath, []byte("# Rules\n"+MarkerStart+"\nhand-written\n"+MarkerEnd+"\n"), 0644)
+	if d, err := BlockDrift(LoadLedger(), configPath); err != nil || d != nil {
+		t.Fatalf("BlockDrift() = %+v, %v before any write, expected nil", d, err)
+	}
+
+	if err := UpdateConfigBlocks(configPath, map[string]string{BlockContext: "first"}); err != nil {
+		t.Fatal(err)
+	}
+	written := read()
+	if err := UpdateConfigBlocks(configPath, map[string]string{BlockContext: "second $1"}); err != nil {
+		t.Fatal(err)
+	}
+	if d, err := BlockDrift(LoadLedger(), configPath); err != nil || d != nil {
+		t.Fatalf("BlockDrift() = %+v, %v after a write, expected nil", d, err)
+	}
+
+	// Restoring an earlier write, as 'aipad undo' does, is not an edit
+	os.WriteFile(configPath, []byte(written), 0644)
+	if d, err := BlockDrift(LoadLedger(), configPath); err != nil || d != nil {
+		t.Fatalf("BlockDrift() = %+v, %v after restoring a write, expected nil", d, err)
+	}
+
+	// The same edit to a block that appears twice is one drift
+	edited := strings.Replace(written, "\nfirst\n", "\nfirst, edited\n", 1)
+	os.WriteFile(configPath, []byte(edited+"\n"+renderBlock(BlockContext, "first, edited")+"\n"), 0644)
+	drifts, err := BlockDrift(LoadLedger(), configPath)
+	if err != nil || len(drifts) != 1 {
+		t.Fatalf("BlockDrift() = %+v, %v after an edit, expected one drift", drifts, err)
+	}
+	d := drifts[0]
+	if d.Path != "CLAUDE.md" || d.Block != BlockContext || d.Base != "second $1" || d.Current != "first, edited" {
+		t.Errorf("BlockDrift() = %+v", d)
+	}
+	if d.String() != "CLAUDE.md (context block)" {
+		t.Errorf("String() = %q, expected %q", d.String(), "CLAUDE.md (context block)")
+	}
+}
+
+func TestBlockDriftAfterUndo(t *testing.T) {
+	dir := chdirTemp(t)
+	os.Mkdir(".aipad", 0755)
+	configPath := filepath.Join(dir, "CLAUDE.md")
+	ledgerPath, err := GetLedgerPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := UpdateConfigBlocks(configPath, map[string]string{BlockContext: "Deploys go through CI."}); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(configPath)
+	edited := strings.Replace(string(data), "through CI.", "through CI on main.", 1)
+	os.WriteFile(configPath, []byte(edited), 0644)
+
+	// A sync folds the edit and rewrites the block; undoing it restores the
+	// edited file and, with it, the ledger
+	op, err := journal.Begin("sync", nil, configPath, ledgerPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := UpdateConfigBlocks(configPath, map[string]string{BlockContext: "Deploys go through CI on main."}); err != nil {
+		t.Fatal(err)
+	}
+	if err := op.Restore(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The next sync must still see the edit instead of overwriting it
+	drifts, err := BlockDrift(LoadLedger(), configPath)
+	if err != nil || len(drifts) != 1 || drifts[0].Current != "Deploys go through CI on main." {
+		t.Fatalf("BlockDrift() = %+v, %v after undo, expected the edit", drifts, err)
+	}
+}
@@ -0,0 +1,131 @@
+package sync
+
+import (
+	"aipad/internal/state"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	gosync "sync"
+)
+
+const (
+	// LedgerFile records what aipad last wrote to each synced file, inside .aipad
+	LedgerFile = "written.json"
+
+	// maxWrittenHashes is how many earlier writes per file are remembered, so
+	// that 'aipad undo' restoring one of them is not mistaken for an edit
+	maxWrittenHashes = 50
+)
+
+// Written is what aipad wrote to one file: the managed block of a config
+// file, or a rules directory copy of the scratchpad
+type Written struct {
+	// Content is the last content written, the base of a three-way merge
+	Content string `json:"content"`
+	// Hashes holds the hashes of the most recent writes, newest last
+	Hashes []string `json:"hashes"`
+}
+
+// Ledger maps file paths, relative to the project root, to what aipad wrote to them
+type Ledger map[string]*Written
+
+// ledgerMu serializes ledger updates from targets synced concurrently
+var ledgerMu gosync.Mutex
+
+// GetLedgerPath returns the path to the ledger file
+func GetLedgerPath() (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cwd, state.AIPadDir, LedgerFile), nil
+}
+
+// LoadLedger reads the ledger. A missing or unreadable ledger is empty, so
+// drift is only detected for files written after it was created.
+func LoadLedger() Ledger {
+	path, err := GetLedgerPath()
+	if err != nil {
+		return Ledger{}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Ledger{}
+	}
+	var ledger Ledger
+	if err := json.Unmarshal(data, &ledger); err != nil || ledger == nil {
+		return Ledger{}
+	}
+	return ledger
+}
+
+// Written reports whether content is something aipad wrote to path itself
+func (l Ledger) Written(path, content string) bool {
+	w, ok := l[ledgerKey(path)]
+	return !ok || slices.Contains(w.Hashes, contentHash(content))
+}
+
+// Base returns the content aipad last wrote to path
+func (l Ledger) Base(path string) (string, bool) {
+	w, ok := l[ledgerKey(path)]
+	if !ok {
+		return "", false
+	}
+	return w.Content, true
+}
+
+// recordWrite notes that content was written to path. Recording is best
+// effort: without a ledger entry, drift is simply not detected.
+func recordWrite(path, content string) {
+	ledgerMu.Lock()
+	defer ledgerMu.Unlock()
+
+	// Only projects with an .aipad directory keep a ledger
+	ledgerPath, err := GetLedgerPath()
+	if err != nil {
+		return
+	}
+	if _, err := os.Stat(filepath.Dir(ledgerPath)); err != nil {
+		return
+	}
+
+	ledger := LoadLedger()
+	key := ledgerKey(path)
+	w, ok := ledger[key]
+	if !ok {
+		w = &Written{}
+		ledger[key] = w
+	}
+	w.Content = content
+	hash := contentHash(content)
+	w.Hashes = append(slices.DeleteFunc(w.Hashes, func(h string) bool { return h == hash }), hash)
+	if len(w.Hashes) > maxWrittenHashes {
+		w.Hashes = w.Hashes[len(w.Hashes)-maxWrittenHashes:]
+	}
+
+	data, err := json.MarshalIndent(ledger, "", "  ")
+	if err != nil {
+		return
+	}
+	os.WriteFile(ledgerPath, data, 0644)
+}
+
+// ledgerKey returns path relative to the project root
+func ledgerKey(path string) string {
+	if cwd, err := os.Getwd(); err == nil && filepath.IsAbs(path) {
+		if rel, err := filepath.Rel(cwd, path); err == nil {
+			return rel
+		}
+	}
+	return filepath.Clean(path)
+}
+
+// contentHash hashes content exactly; unlike crypto.GenerateHash, case and
+// surrounding whitespace count
+func contentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,120 @@
+package sync
+
+import (
+	"aipad/internal/config"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// RulesScratchpadFile is the name of the scratchpad inside a provider's rules directory
+const RulesScratchpadFile = "scratchpad.md"
+
+// RulesLink describes the scratchpad in a provider's rules directory
+type RulesLink struct {
+	// Path is the file's path relative to the project root
+	Path string
+	// Mode is how the file gets the scratchpad: copy, symlink or hardlink; empty when it is missing
+	Mode string
+	// Target is where a symlink points
+	Target string
+	// Stale is set when the file no longer shows the scratchpad: a copy that
+	// differs from it, or a symlink that does not resolve to it
+	Stale bool
+}
+
+// Linked reports whether the file is a symlink or hard link rather than a copy
+func (l RulesLink) Linked() bool {
+	return l.Mode == config.LinkSymlink || l.Mode == config.LinkHardlink
+}
+
+// LinkScratchpadToRules puts the scratchpad into the provider's rules
+// directory according to mode. Links always show the current scratchpad,
+// so they never go stale between syncs. When the filesystem rejects a link
+// the scratchpad is copied instead. It returns the mode actually used.
+func LinkScratchpadToRules(scratchpadPath, rulesDir, mode string) (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	scratchpadPath, err = filepath.Abs(scratchpadPath)
+	if err != nil {
+		return "", err
+	}
+	destPath := filepath.Join(cwd, rulesDir, RulesScratchpadFile)
+	if destPath == scratchpadPath {
+		return "", fmt.Errorf("rules directory %s holds the scratchpad itself", rulesDir)
+	}
+
+	// Keep a link that already shows the scratchpad
+	link, err := InspectRulesLink(scratchpadPath, rulesDir)
+	if err != nil {
+		return "", err
+	}
+	if mode != config.LinkCopy && link.Mode == mode && !link.Stale {
+		return mode, nil
+	}
+
+	// Remove whatever is there so a new copy never writes through an old link
+	if err := os.Remove(destPath); err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	switch mode {
+	case config.LinkSymlink:
+		// A relative target keeps working when the project is moved
+		target, err := filepath.Rel(filepath.Dir(destPath), scratchpadPath)
+		if err == nil && os.Symlink(target, destPath) == nil {
+			return mode, nil
+		}
+	case config.LinkHardlink:
+		if os.Link(scratchpadPath, destPath) == nil {
+			return mode, nil
+		}
+	}
+	return config.LinkCopy, CopyScratchpadToRules(scratchpadPath, rulesDir)
+}
+
+// InspectRulesLink reports how the scratchpad in a provider's rules directory
+// relates to the scratchpad at scratchpadPath
+func InspectRulesLink(scratchpadPath, rulesDir string) (RulesLink, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return RulesLink{}, err
+	}
+	link := RulesLink{Path: filepath.Join(rulesDir, RulesScratchpadFile)}
+	destPath := filepath.Join(cwd, link.Path)
+
+	info, err := os.Lstat(destPath)
+	if os.IsNotExist(err) {
+		return link, nil
+	}
+	if err != nil {
+		return link, err
+	}
+	scratchpadInfo, scratchpadErr := os.Stat(scratchpadPath)
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		link.Mode = config.LinkSymlink
+		if link.Target, err = os.Readlink(destPath); err != nil {
+			return link, err
+		}
+		resolved, err := os.Stat(destPath)
+		link.Stale = err != nil || scratchpadErr != nil || !os.SameFile(resolved, scratchpadInfo)
+		return link, nil
+	}
+
+	if scratchpadErr == nil && os.SameFile(info, scratchpadInfo) {
+		link.Mode = config.LinkHardlink
+		return link, nil
+	}
+
+	link.Mode = config.LinkCopy
+	copied, err := os.ReadFile(destPath)
+	if err != nil {
+		return link, err
+	}
+	original, err := os.ReadFile(scratchpadPath)
+	link.Stale = err != nil || !bytes.Equal(copied, original)
+	return link, nil
+}
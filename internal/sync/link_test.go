@@ -0,0 +1,83 @@
+package sync
+
+import (
+	"aipad/internal/config"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLinkScratchpadToRules(t *testing.T) {
+	tests := []struct {
+		name string
+		mode string
+	}{
+		{"copy", config.LinkCopy},
+		{"symlink", config.LinkSymlink},
+		{"hardlink", config.LinkHardlink},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := chdirTemp(t)
+			scratchpadPath := filepath.Join(dir, ".aipad", "scratchpad.md")
+			os.MkdirAll(filepath.Dir(scratchpadPath), 0755)
+			os.MkdirAll("rules", 0755)
+			os.WriteFile(scratchpadPath, []byte("first"), 0644)
+
+			// Start from a stale copy, as left by earlier versions
+			os.WriteFile(filepath.Join("rules", RulesScratchpadFile), []byte("stale"), 0644)
+
+			used, err := LinkScratchpadToRules(scratchpadPath, "rules", tt.mode)
+			if err != nil {
+				t.Fatalf("LinkScratchpadToRules() error = %v", err)
+			}
+			if used != tt.mode {
+				t.Skipf("filesystem fell back to %s", used)
+			}
+			link, err := InspectRulesLink(scratchpadPath, "rules")
+			if err != nil {
+				t.Fatalf("InspectRulesLink() error = %v", err)
+			}
+			if link.Mode != tt.mode || link.Stale {
+				t.Errorf("InspectRulesLink() = %+v, expected a current %s", link, tt.mode)
+			}
+
+			// Links follow the scratchpad as it changes; copies go stale
+			os.WriteFile(scratchpadPath, []byte("second"), 0644)
+			data, _ := os.ReadFile(filepath.Join("rules", RulesScratchpadFile))
+			link, _ = InspectRulesLink(scratchpadPath, "rules")
+			if linked := tt.mode != config.LinkCopy; linked != (string(data) == "second") || linked == link.Stale {
+				t.Errorf("after a scratchpad change: content %q, stale %v", data, link.Stale)
+			}
+
+			// Switching back to a copy never writes through the old link
+			if _, err := LinkScratchpadToRules(scratchpadPath, "rules", config.LinkCopy); err != nil {
+				t.Fatalf("LinkScratchpadToRules() error = %v", err)
+			}
+			link, _ = InspectRulesLink(scratchpadPath, "rules")
+			if link.Mode != config.LinkCopy || link.Stale {
+				t.Errorf("InspectRulesLink() = %+v, expected a current copy", link)
+			}
+			if data, _ := os.ReadFile(scratchpadPath); string(data) != "second" {
+				t.Errorf("scratchpad = %q, expected it untouched", data)
+			}
+		})
+	}
+}
+
+func TestInspectRulesLinkBroken(t *testing.T) {
+	dir := chdirTemp(t)
+	os.MkdirAll("rules", 0755)
+	if err := os.Symlink("missing.md", filepath.Join("rules", RulesScratchpadFile)); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	link, err := InspectRulesLink(filepath.Join(dir, "scratchpad.md"), "rules")
+	if err != nil {
+		t.Fatalf("InspectRulesLink() error = %v", err)
+	}
+	if link.Mode != config.LinkSymlink || link.Target != "missing.md" || !link.Stale {
+		t.Errorf("InspectRulesLink() = %+v, expected a stale symlink to missing.md", link)
+	}
+}
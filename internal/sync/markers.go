@@ -0,0 +1,143 @@
+package sync
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// MarkerProblem is a managed block marker that sync cannot pair up, or a block it would update twice
+type MarkerProblem struct {
+	// Line is the 1-based line of the marker
+	Line    int
+	Problem string
+	// Fixable is set when RepairMarkers removes the problem without touching hand-written text
+	Fixable bool
+	// cut is the part of the file RepairMarkers removes
+	cut [2]int
+}
+
+// markerPattern matches every AIPad marker, including ones with unknown block names
+var markerPattern = regexp.MustCompile(`<!-- (/?)AIPAD:([a-z0-9_-]+) -->|<!-- AIPAD_CONTEXT_(START|END) -->`)
+
+// marker is one marker found in a config file
+type marker struct {
+	start, end int
+	// name is empty for a legacy marker
+	name    string
+	closing bool
+	known   bool
+}
+
+func findMarkers(content string) []marker {
+	var markers []marker
+	for _, loc := range markerPattern.FindAllStringSubmatchIndex(content, -1) {
+		m := marker{start: loc[0], end: loc[1], known: true}
+		if loc[4] >= 0 {
+			m.name = content[loc[4]:loc[5]]
+			m.closing = loc[3] > loc[2]
+			m.known = slices.Contains(BlockNames, m.name)
+		} else {
+			m.closing = content[loc[6]:loc[7]] == "END"
+		}
+		markers = append(markers, m)
+	}
+	return markers
+}
+
+// blockLabel names a block in messages, e.g. "context block"
+func blockLabel(name string) string {
+	if name == "" {
+		return "legacy block"
+	}
+	return name + " block"
+}
+
+// CheckMarkers finds markers in a config file that do not form blocks, such
+// as a start marker without an end marker or markers in the wrong order, and
+// blocks that appear more than once. Sync leaves broken markers alone, so
+// they end up as stray text next to freshly appended blocks.
+func CheckMarkers(content string) []MarkerProblem {
+	line := func(pos int) int { return strings.Count(content[:pos], "\n") + 1 }
+	var problems []MarkerProblem
+	stray := func(m marker, format string, args ...any) {
+		problems = append(problems, MarkerProblem{Line: line(m.start), Problem: fmt.Sprintf(format, args...), Fixable: true, cut: markerLine(content, m)})
+	}
+
+	type block struct {
+		line    int
+		content string
+	}
+	first := make(map[string]block)
+	var open *marker
+	for _, m := range findMarkers(content) {
+		switch {
+		case !m.known:
+			problems = append(problems, MarkerProblem{Line: line(m.start), Problem: fmt.Sprintf("unknown block %q", m.name)})
+		case !m.closing:
+			if open != nil {
+				stray(*open, "%s is not closed before the %s starts on line %d", blockLabel(open.name), blockLabel(m.name), line(m.start))
+			}
+			open = &m
+		case open == nil || open.name != m.name:
+			stray(m, "end marker of the %s has no start marker", blockLabel(m.name))
+		default:
+			text := content[open.end:m.start]
+			if b, ok := first[m.name]; !ok {
+				first[m.name] = block{line: line(open.start), content: text}
+			} else if b.content == text {
+				cut := [2]int{markerLine(content, *open)[0], markerLine(content, m)[1]}
+				problems = append(problems, MarkerProblem{Line: line(open.start), Problem: fmt.Sprintf("duplicate %s (first on line %d)", blockLabel(m.name), b.line), Fixable: true, cut: cut})
+			} else {
+				problems = append(problems, MarkerProblem{Line: line(open.start), Problem: fmt.Sprintf("duplicate %s (first on line %d) with different content; remove one by hand", blockLabel(m.name), b.line)})
+			}
+			open = nil
+		}
+	}
+	if open != nil {
+		stray(*open, "%s has no end marker", blockLabel(open.name))
+	}
+	slices.SortStableFunc(problems, func(a, b MarkerProblem) int { return a.Line - b.Line })
+	return problems
+}
+
+// markerLine returns the part of content to remove with marker m: its whole
+// line if the marker stands alone on it, otherwise just the marker
+func markerLine(content string, m marker) [2]int {
+	start := strings.LastIndex(content[:m.start], "\n") + 1
+	end := len(content)
+	if i := strings.Index(content[m.end:], "\n"); i >= 0 {
+		end = m.end + i + 1
+	}
+	if strings.TrimSpace(content[start:end]) != content[m.start:m.end] {
+		return [2]int{m.start, m.end}
+	}
+	return [2]int{start, end}
+}
+
+// RepairMarkers removes stray markers and duplicate blocks whose content
+// matches the first copy. Text between stray markers is kept. It returns the
+// repaired content and the number of problems fixed.
+func RepairMarkers(content string) (string, int) {
+	var cuts [][2]int
+	for _, p := range CheckMarkers(content) {
+		if p.Fixable {
+			cuts = append(cuts, p.cut)
+		}
+	}
+	slices.SortFunc(cuts, func(a, b [2]int) int { return a[0] - b[0] })
+
+	var b strings.Builder
+	pos := 0
+	for _, cut := range cuts {
+		if cut[0] < pos {
+			// Inside a duplicate block that is removed as a whole
+			continue
+		}
+		b.WriteString(content[pos:cut[0]])
+		pos = cut[1]
+	}
+	b.WriteString(content[pos:])
+	return b.String(), len(cuts)
+}
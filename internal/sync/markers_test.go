@@ -0,0 +1,95 @@
+package sync
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestCheckMarkers(t *testing.T) {
+	todos := renderBlock(BlockTodos, "- [ ] task")
+	context := renderBlock(BlockContext, "context")
+
+	tests := []struct {
+		name     string
+		content  string
+		expected []string
+		repaired string
+	}{
+		{
+			name:     "well formed",
+			content:  "# Rules\n" + todos + "\n- Use pnpm\n" + context + "\n",
+			repaired: "# Rules\n" + todos + "\n- Use pnpm\n" + context + "\n",
+		},
+		{
+			name:     "start without end",
+			content:  "# Rules\n" + BlockStart(BlockContext) + "\nold context\n- Use pnpm\n",
+			expected: []string{"2: context block has no end marker"},
+			repaired: "# Rules\nold context\n- Use pnpm\n",
+		},
+		{
+			name:     "legacy start without end",
+			content:  MarkerStart + "\nold\n",
+			expected: []string{"1: legacy block has no end marker"},
+			repaired: "old\n",
+		},
+		{
+			name:     "end before start",
+			content:  BlockEnd(BlockTodos) + "\n- Use pnpm\n" + BlockStart(BlockTodos) + "\n",
+			expected: []string{"1: end marker of the todos block has no start marker", "3: todos block has no end marker"},
+			repaired: "- Use pnpm\n",
+		},
+		{
+			name:     "nested",
+			content:  BlockStart(BlockContext) + "\nold\n" + todos + "\n" + BlockEnd(BlockContext) + "\n",
+			expected: []string{"1: context block is not closed before the todos block starts on line 3", "6: end marker of the context block has no start marker"},
+			repaired: "old\n" + todos + "\n",
+		},
+		{
+			name:     "duplicate",
+			content:  context + "\n- Use pnpm\n" + context + "\n",
+			expected: []string{"5: duplicate context block (first on line 1)"},
+			repaired: context + "\n- Use pnpm\n",
+		},
+		{
+			name:     "duplicate with different content",
+			content:  context + "\n" + renderBlock(BlockContext, "edited") + "\n",
+			expected: []string{"4: duplicate context block (first on line 1) with different content; remove one by hand"},
+			repaired: context + "\n" + renderBlock(BlockContext, "edited") + "\n",
+		},
+		{
+			name:     "unknown block",
+			content:  "<!-- AIPAD:contxt -->\n",
+			expected: []string{`1: unknown block "contxt"`},
+			repaired: "<!-- AIPAD:contxt -->\n",
+		},
+		{
+			name:     "marker inside a line",
+			content:  "See " + BlockEnd(BlockContext) + " below\n",
+			expected: []string{"1: end marker of the context block has no start marker"},
+			repaired: "See  below\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, p := range CheckMarkers(tt.content) {
+				got = append(got, fmt.Sprintf("%d: %s", p.Line, p.Problem))
+			}
+			if !slices.Equal(got, tt.expected) {
+				t.Errorf("CheckMarkers() = %q, expected %q", got, tt.expected)
+			}
+
+			repaired, _ := RepairMarkers(tt.content)
+			if repaired != tt.repaired {
+				t.Errorf("RepairMarkers() = %q, expected %q", repaired, tt.repaired)
+			}
+			for _, p := range CheckMarkers(repaired) {
+				if p.Fixable {
+					t.Errorf("RepairMarkers() left %q", p.Problem)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package sync
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Rule is a piece of hand-written guidance found in a provider config file
+type Rule struct {
+	Text string
+	// Line is the 1-based line the rule starts on
+	Line int
+}
+
+var (
+	listItemPattern = regexp.MustCompile(`^\s*([-*+]|\d+[.)])\s+(\[[ xX]\]\s+)?`)
+	headingPattern  = regexp.MustCompile(`^\s*#{1,6}\s`)
+	fencePattern    = regexp.MustCompile("^\\s*(```|~~~)")
+)
+
+// StripManagedBlocks returns content without the AIPad managed blocks,
+// leaving only what people wrote by hand. The blocks' lines are blanked
+// rather than removed so line numbers still match the file.
+func StripManagedBlocks(content string) string {
+	var b strings.Builder
+	pos := 0
+	for _, span := range findBlocks(content) {
+		b.WriteString(content[pos:span.Start])
+		b.WriteString(strings.Repeat("\n", strings.Count(content[span.Start:span.End], "\n")))
+		pos = span.End
+	}
+	b.WriteString(content[pos:])
+	return b.String()
+}
+
+// HumanRules splits the hand-written part of a config file into rules: each
+// list item is a rule, and so is each paragraph of prose. Headings and code
+// blocks are not rules.
+func HumanRules(content string) []Rule {
+	var rules []Rule
+	var current []string
+	start := 0
+	flush := func() {
+		if text := strings.TrimSpace(strings.Join(current, " ")); text != "" {
+			rules = append(rules, Rule{Text: text, Line: start})
+		}
+		current = nil
+	}
+
+	inFence := false
+	for i, line := range strings.Split(StripManagedBlocks(content), "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case fencePattern.MatchString(line):
+			flush()
+			inFence = !inFence
+		case inFence:
+			// Code is not guidance to compare against
+		case trimmed == "" || headingPattern.MatchString(line) || strings.HasPrefix(trimmed, "<!--"):
+			flush()
+		case listItemPattern.MatchString(line):
+			flush()
+			current = []string{listItemPattern.ReplaceAllString(line, "")}
+			start = i + 1
+		default:
+			if len(current) == 0 {
+				start = i + 1
+			}
+			current = append(current, trimmed)
+		}
+	}
+	flush()
+	return rules
+}
@@ -0,0 +1,75 @@
+package sync
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHumanRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected []Rule
+	}{
+		{
+			name:     "empty",
+			content:  "",
+			expected: nil,
+		},
+		{
+			name:    "list items",
+			content: "# Rules\n\n- Use pnpm\n* Run tests before\n  pushing\n1. Never commit secrets\n- [ ] Migrate to Go 1.24\n",
+			expected: []Rule{
+				{Text: "Use pnpm", Line: 3},
+				{Text: "Run tests before pushing", Line: 4},
+				{Text: "Never commit secrets", Line: 6},
+				{Text: "Migrate to Go 1.24", Line: 7},
+			},
+		},
+		{
+			name:    "paragraphs",
+			content: "Sessions are stored\nin Postgres.\n\nDeploys go through CI.",
+			expected: []Rule{
+				{Text: "Sessions are stored in Postgres.", Line: 1},
+				{Text: "Deploys go through CI.", Line: 4},
+			},
+		},
+		{
+			name:    "code blocks are skipped",
+			content: "Build with:\n```bash\nmake build\n\n- not a rule\n```\n- Use make",
+			expected: []Rule{
+				{Text: "Build with:", Line: 1},
+				{Text: "Use make", Line: 7},
+			},
+		},
+		{
+			name:    "managed block is skipped",
+			content: "- Use pnpm\n\n" + MarkerStart + "\n## AIPad Context Management\n\n- Save context often\n" + MarkerEnd + "\n\n- Prefer small PRs\n",
+			expected: []Rule{
+				{Text: "Use pnpm", Line: 1},
+				{Text: "Prefer small PRs", Line: 9},
+			},
+		},
+		{
+			name:    "named blocks are skipped",
+			content: BlockStart(BlockTodos) + "\n- [ ] Migrate the sessions\n" + BlockEnd(BlockTodos) + "\n- Use pnpm\n" + BlockStart(BlockContext) + "\nSessions are in Postgres.\n" + BlockEnd(BlockContext) + "\n",
+			expected: []Rule{
+				{Text: "Use pnpm", Line: 4},
+			},
+		},
+		{
+			name:     "unterminated managed block is kept",
+			content:  MarkerStart + "\n- Use pnpm\n",
+			expected: []Rule{{Text: "Use pnpm", Line: 2}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := HumanRules(tt.content)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("HumanRules() = %+v, expected %+v", got, tt.expected)
+			}
+		})
+	}
+}
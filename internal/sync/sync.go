@@ -1,39 +1,26 @@
 package sync
 
 import (
+	"aipad/internal/budget"
+	"aipad/internal/instructions"
+	"aipad/internal/scratchpad"
+	"aipad/internal/state"
+	"aipad/internal/todo"
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
+	"slices"
 	"strings"
+	"time"
 )
 
+// Markers of the single managed block written by earlier versions. Files
+// that still have one are migrated to named blocks on the next sync.
 const (
 	MarkerStart = "<!-- AIPAD_CONTEXT_START -->"
 	MarkerEnd   = "<!-- AIPAD_CONTEXT_END -->"
 )
 
-// AgentAwarenessInstructions contains the text to inject into config files
-const AgentAwarenessInstructions = `## AIPad Context Management
-
-This project uses **AIPad** for context switching between AI assistants.
-
-### How to Save Context
-When you complete a significant task or conversation milestone, save the context using:
-` + "```bash" + `
-aipad convo "Summary of what was accomplished"
-` + "```" + `
-
-### When to Save
-- After completing a feature or bug fix
-- Before switching to a different topic
-- When the user requests a context save
-- At natural conversation breakpoints
-
-### Reading Context
-The shared scratchpad is located at ` + "`.aipad/scratchpad.md`" + `. Review it to understand prior context.
-`
-
 // EnsureRulesDir creates the provider's rules directory if it doesn't exist
 func EnsureRulesDir(rulesDir string) error {
 	cwd, err := os.Getwd()
@@ -58,49 +45,332 @@ func CopyScratchpadToRules(scratchpadPath, rulesDir string) error {
 	}
 
 	// Write to rules directory
-	destPath := filepath.Join(cwd, rulesDir, "scratchpad.md")
-	return os.WriteFile(destPath, content, 0644)
+	destPath := filepath.Join(cwd, rulesDir, RulesScratchpadFile)
+	if err := os.WriteFile(destPath, content, 0644); err != nil {
+		return err
+	}
+	recordWrite(destPath, string(content))
+	return nil
 }
 
-// UpdateConfigWithManagedBlock updates the config file with managed block content
-func UpdateConfigWithManagedBlock(configPath string, newContent string) error {
-	// Read existing content
-	existingContent, err := os.ReadFile(configPath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			// Create new file with markers
-			content := fmt.Sprintf("%s\n%s\n%s\n", MarkerStart, newContent, MarkerEnd)
-			return os.WriteFile(configPath, []byte(content), 0644)
+// contextSections defines the order in which entry kinds appear in the managed block
+var contextSections = []struct {
+	Kind  string
+	Title string
+}{
+	{scratchpad.KindDecision, "Key Decisions"},
+	{scratchpad.KindTodo, "Open Work"},
+	{scratchpad.KindBug, "Known Bugs"},
+	{scratchpad.KindNote, "Recent Log"},
+}
+
+// RenderContext groups entries by kind into the sections of the managed block.
+// Pinned entries come first regardless of kind. The Recent Log lists notes
+// newest first; other sections keep scratchpad order.
+func RenderContext(entries []scratchpad.Entry) string {
+	var b strings.Builder
+
+	var pinned []scratchpad.Entry
+	for _, e := range entries {
+		if e.Pinned {
+			pinned = append(pinned, e)
+		}
+	}
+	if len(pinned) > 0 {
+		b.WriteString("### Pinned\n\n")
+		for _, e := range pinned {
+			b.WriteString(renderEntry(e))
+		}
+	}
+
+	for _, section := range contextSections {
+		var sectionEntries []scratchpad.Entry
+		for _, e := range entries {
+			if !e.Pinned && e.Kind == section.Kind {
+				sectionEntries = append(sectionEntries, e)
+			}
+		}
+		if len(sectionEntries) == 0 {
+			continue
+		}
+		if section.Kind == scratchpad.KindNote {
+			slices.Reverse(sectionEntries)
+		}
+
+		b.WriteString(sectionHeading(section.Kind))
+		for _, e := range sectionEntries {
+			b.WriteString(renderEntry(e))
+		}
+	}
+	if b.Len() == 0 {
+		return ""
+	}
+	return strings.TrimRight(b.String(), "\n") + "\n"
+}
+
+// sectionHeading returns the heading of the section entries of kind are rendered in
+func sectionHeading(kind string) string {
+	for _, section := range contextSections {
+		if section.Kind == kind {
+			return "### " + section.Title + "\n\n"
 		}
-		return err
 	}
+	return ""
+}
 
-	contentStr := string(existingContent)
+func renderEntry(e scratchpad.Entry) string {
+	header := fmt.Sprintf("#### [%s] (id: %s)", e.Timestamp, e.ID)
+	for _, tag := range e.Tags {
+		header += " #" + tag
+	}
+	if len(e.Supersedes) > 0 {
+		header += fmt.Sprintf(" (supersedes %s)", strings.Join(e.Supersedes, ", "))
+	}
+	if !e.Expires.IsZero() {
+		header += fmt.Sprintf(" (expires %s)", e.Expires.Format(scratchpad.TimestampFormat))
+	}
+	return header + "\n" + e.Content + "\n\n"
+}
 
-	// Check if markers exist
-	if strings.Contains(contentStr, MarkerStart) && strings.Contains(contentStr, MarkerEnd) {
-		// Replace content between markers
-		pattern := regexp.MustCompile(`(?s)` + regexp.QuoteMeta(MarkerStart) + `.*?` + regexp.QuoteMeta(MarkerEnd))
-		replacement := fmt.Sprintf("%s\n%s\n%s", MarkerStart, newContent, MarkerEnd)
-		contentStr = pattern.ReplaceAllString(contentStr, replacement)
-	} else {
-		// Append markers and content at the end
-		contentStr = contentStr + "\n" + MarkerStart + "\n" + newContent + "\n" + MarkerEnd + "\n"
+// RenderTodos renders open todo items as the checklist of the todos block
+func RenderTodos(items []todo.Item) string {
+	if len(items) == 0 {
+		return ""
 	}
 
-	return os.WriteFile(configPath, []byte(contentStr), 0644)
+	var b strings.Builder
+	b.WriteString("## Open Tasks\n\n")
+	for _, item := range items {
+		attrs := []string{"id: " + item.ID}
+		if item.Owner != "" {
+			attrs = append(attrs, "owner: "+item.Owner)
+		}
+		if len(item.Entries) > 0 {
+			attrs = append(attrs, "see: "+strings.Join(item.Entries, ", "))
+		}
+		// Keep each item on one checklist line
+		text := strings.Join(strings.Fields(item.Text), " ")
+		fmt.Fprintf(&b, "- [ ] %s (%s)\n", text, strings.Join(attrs, ", "))
+	}
+	return b.String()
 }
 
-// SyncProviderConfig syncs the scratchpad content into the provider's config file managed block
-func SyncProviderConfig(configPath, scratchpadPath string) error {
+// Superseded returns the IDs of entries that another entry has replaced,
+// whether recorded on the old entry or only on its replacement
+func Superseded(entries []scratchpad.Entry) map[string]bool {
+	superseded := make(map[string]bool)
+	for _, e := range entries {
+		if e.SupersededBy != "" {
+			superseded[e.ID] = true
+		}
+		for _, id := range e.Supersedes {
+			superseded[id] = true
+		}
+	}
+	return superseded
+}
+
+// Options controls which scratchpad content ends up in the managed block
+type Options struct {
+	// Provider selects the instructions template; empty uses the default
+	Provider string
+	// Session supplies the session ID and provider files the instructions
+	// template shows; nil leaves them empty
+	Session *state.State
+	// Todos holds the open tasks rendered in the todos block
+	Todos  []todo.Item
+	Filter scratchpad.Filter
+	// Budget caps the size of the managed block; the zero Budget is unlimited
+	Budget budget.Budget
+}
+
+// Block holds the rendered managed blocks of a config file
+type Block struct {
+	// Parts maps block names to their content
+	Parts map[string]string
+	// Content is every block joined in BlockNames order, the size a budget limits
+	Content string
+	// Omitted holds the entries left out to fit the budget
+	Omitted []scratchpad.Entry
+}
+
+// RecentWindow is how old an entry may be to count as recent when filling a budget
+const RecentWindow = 7 * 24 * time.Hour
+
+// BuildManagedBlock renders the managed blocks for the scratchpad at scratchpadPath
+func BuildManagedBlock(scratchpadPath string, opts Options) (Block, error) {
 	// Read scratchpad content
 	scratchpadContent, err := os.ReadFile(scratchpadPath)
 	if err != nil {
-		return fmt.Errorf("failed to read scratchpad: %w", err)
+		return Block{}, fmt.Errorf("failed to read scratchpad: %w", err)
+	}
+
+	all := scratchpad.Parse(string(scratchpadContent))
+	superseded := Superseded(all)
+
+	// Expired and superseded entries are dropped, even pinned ones; pinned
+	// entries always survive the filter
+	now := time.Now()
+	var entries []scratchpad.Entry
+	for _, e := range all {
+		if e.Expired(now) || superseded[e.ID] {
+			continue
+		}
+		if e.Pinned || opts.Filter.Match(e) {
+			entries = append(entries, e)
+		}
+	}
+
+	text, err := renderInstructions(opts, entries)
+	if err != nil {
+		return Block{}, err
+	}
+
+	render := func(entries, omitted []scratchpad.Entry) Block {
+		// The scratchpad entries, then what was left out
+		var sections []string
+		for _, section := range []string{RenderContext(entries), RenderOmitted(omitted)} {
+			if section != "" {
+				sections = append(sections, section)
+			}
+		}
+		parts := map[string]string{
+			BlockInstructions: text,
+			BlockTodos:        RenderTodos(opts.Todos),
+			BlockContext:      "## Current Session Context\n\n" + strings.Join(sections, "\n"),
+		}
+		var content []string
+		for _, name := range BlockNames {
+			content = append(content, parts[name])
+		}
+		return Block{Parts: parts, Content: strings.Join(content, "\n"), Omitted: omitted}
+	}
+
+	if opts.Budget.Unlimited() {
+		return render(entries, nil), nil
+	}
+
+	// Fill the budget by priority: pinned entries are always kept, then
+	// recent, tagged and remaining entries are added while they still fit.
+	// Each entry's cost is measured once and added to the size of the block
+	// without any unpinned entries, whose summary of omitted entries only
+	// shrinks as entries are added.
+	kept := make(map[string]bool)
+	for _, e := range entries {
+		if e.Pinned {
+			kept[e.ID] = true
+		}
+	}
+	split := func() (in, out []scratchpad.Entry) {
+		for _, e := range entries {
+			if kept[e.ID] {
+				in = append(in, e)
+			} else {
+				out = append(out, e)
+			}
+		}
+		return in, out
+	}
+	limit, _ := opts.Budget.Limit()
+	used := opts.Budget.Size(render(split()).Content)
+	headed := make(map[string]bool)
+	var added []string
+	for _, e := range byPriority(entries, now) {
+		cost := opts.Budget.Size(renderEntry(e))
+		if !headed[e.Kind] {
+			cost += opts.Budget.Size(sectionHeading(e.Kind))
+		}
+		if used+cost > limit {
+			continue
+		}
+		used += cost
+		kept[e.ID] = true
+		headed[e.Kind] = true
+		added = append(added, e.ID)
+	}
+
+	// Token counts do not add up exactly, so check the result and leave out
+	// the last entries added until it fits
+	block := render(split())
+	for len(added) > 0 && !opts.Budget.Fits(block.Content) {
+		delete(kept, added[len(added)-1])
+		added = added[:len(added)-1]
+		block = render(split())
+	}
+	return block, nil
+}
+
+// renderInstructions renders the provider's instructions template for the synced entries and open tasks
+func renderInstructions(opts Options, entries []scratchpad.Entry) (string, error) {
+	data := instructions.Data{Provider: opts.Provider, Entries: len(entries), Kinds: make(map[string]int), Todos: opts.Todos}
+	for _, e := range entries {
+		data.Kinds[e.Kind]++
+		if e.Pinned {
+			data.Pinned++
+		}
+	}
+	if opts.Session != nil {
+		data.SessionID = opts.Session.SessionID
+		if p, ok := opts.Session.Providers[opts.Provider]; ok {
+			data.ConfigFile, data.RulesDir = p.ConfigFile, p.RulesDir
+		}
 	}
+	return instructions.Render(opts.Provider, data)
+}
 
-	// Build the managed block content
-	managedContent := AgentAwarenessInstructions + "\n## Current Session Context\n\n" + string(scratchpadContent)
+// byPriority returns the unpinned entries in the order they are added to a
+// budget: entries newer than RecentWindow, then tagged entries, then the
+// rest, each newest first
+func byPriority(entries []scratchpad.Entry, now time.Time) []scratchpad.Entry {
+	var recent, tagged, rest []scratchpad.Entry
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		switch {
+		case e.Pinned:
+			continue
+		case isRecent(e, now):
+			recent = append(recent, e)
+		case len(e.Tags) > 0:
+			tagged = append(tagged, e)
+		default:
+			rest = append(rest, e)
+		}
+	}
+	return slices.Concat(recent, tagged, rest)
+}
 
-	return UpdateConfigWithManagedBlock(configPath, managedContent)
+func isRecent(e scratchpad.Entry, now time.Time) bool {
+	created, err := time.ParseInLocation(scratchpad.TimestampFormat, e.Timestamp, time.Local)
+	return err == nil && now.Sub(created) <= RecentWindow
+}
+
+// RenderOmitted summarizes the entries left out of the managed block and
+// points to the scratchpad, which always has all of them
+func RenderOmitted(entries []scratchpad.Entry) string {
+	if len(entries) == 0 {
+		return ""
+	}
+
+	counts := make(map[string]int)
+	for _, e := range entries {
+		counts[e.Kind]++
+	}
+	var kinds []string
+	for _, section := range contextSections {
+		if n := counts[section.Kind]; n > 0 {
+			kinds = append(kinds, instructions.Plural(n, section.Kind))
+		}
+	}
+
+	return fmt.Sprintf("### Omitted\n\n%s left out to fit the context budget (%s). Read `.aipad/scratchpad.md` for the full context.\n",
+		instructions.Plural(len(entries), "entry"), strings.Join(kinds, ", "))
+}
+
+// SyncProviderConfig syncs the scratchpad content into the provider's config file managed blocks
+func SyncProviderConfig(configPath, scratchpadPath string, opts Options) (Block, error) {
+	block, err := BuildManagedBlock(scratchpadPath, opts)
+	if err != nil {
+		return Block{}, err
+	}
+	return block, UpdateConfigBlocks(configPath, block.Parts)
 }
@@ -0,0 +1,280 @@
+package sync
+
+import (
+	"aipad/internal/budget"
+	"aipad/internal/scratchpad"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func chdirTemp(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	return dir
+}
+
+func TestByPriority(t *testing.T) {
+	now := time.Now()
+	at := func(age time.Duration) string { return now.Add(-age).Format(scratchpad.TimestampFormat) }
+	day := 24 * time.Hour
+	entries := []scratchpad.Entry{
+		{ID: "old", Timestamp: at(30 * day)},
+		{ID: "old-tagged", Timestamp: at(20 * day), Tags: []string{"db"}},
+		{ID: "pinned", Timestamp: at(15 * day), Pinned: true},
+		{ID: "older", Timestamp: at(10 * day)},
+		{ID: "recent", Timestamp: at(2 * day)},
+		{ID: "recent-tagged", Timestamp: at(day), Tags: []string{"db"}},
+	}
+
+	var got []string
+	for _, e := range byPriority(entries, now) {
+		got = append(got, e.ID)
+	}
+	expected := "recent-tagged recent old-tagged older old"
+	if strings.Join(got, " ") != expected {
+		t.Errorf("byPriority() = %v, expected %s", got, expected)
+	}
+}
+
+func TestRenderContext(t *testing.T) {
+	entry := func(id, kind string, pinned bool) scratchpad.Entry {
+		return scratchpad.Entry{ID: id, Timestamp: "2024-01-01 10:00:00", Kind: kind, Content: "content of " + id, Pinned: pinned}
+	}
+	tests := []struct {
+		name    string
+		entries []scratchpad.Entry
+		// expected lists the section titles and entry IDs in rendered order
+		expected []string
+	}{
+		{"empty", nil, nil},
+		{
+			"section order",
+			[]scratchpad.Entry{
+				entry("note1", scratchpad.KindNote, false),
+				entry("bug1", scratchpad.KindBug, false),
+				entry("todo1", scratchpad.KindTodo, false),
+				entry("dec1", scratchpad.KindDecision, false),
+				entry("pin1", scratchpad.KindNote, true),
+			},
+			[]string{"Pinned", "pin1", "Key Decisions", "dec1", "Open Work", "todo1", "Known Bugs", "bug1", "Recent Log", "note1"},
+		},
+		{
+			"recent log newest first",
+			[]scratchpad.Entry{
+				entry("note1", scratchpad.KindNote, false),
+				entry("dec1", scratchpad.KindDecision, false),
+				entry("note2", scratchpad.KindNote, false),
+				entry("dec2", scratchpad.KindDecision, false),
+				entry("note3", scratchpad.KindNote, false),
+			},
+			[]string{"Key Decisions", "dec1", "dec2", "Recent Log", "note3", "note2", "note1"},
+		},
+		{
+			"empty sections skipped",
+			[]scratchpad.Entry{
+				entry("bug1", scratchpad.KindBug, false),
+				entry("pin1", scratchpad.KindDecision, true),
+			},
+			[]string{"Pinned", "pin1", "Known Bugs", "bug1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := RenderContext(tt.entries)
+			var got []string
+			for _, line := range strings.Split(content, "\n") {
+				if title, ok := strings.CutPrefix(line, "### "); ok {
+					got = append(got, title)
+				} else if _, rest, ok := strings.Cut(line, "(id: "); ok && strings.HasPrefix(line, "#### ") {
+					id, _, _ := strings.Cut(rest, ")")
+					got = append(got, id)
+				}
+			}
+			if strings.Join(got, " ") != strings.Join(tt.expected, " ") {
+				t.Errorf("RenderContext() order = %v, expected %v\n%s", got, tt.expected, content)
+			}
+			if len(tt.entries) == 0 && content != "" {
+				t.Errorf("RenderContext(nil) = %q, expected empty", content)
+			}
+			if len(tt.entries) > 0 && !strings.HasSuffix(content, "\n") || strings.HasSuffix(content, "\n\n") {
+				t.Errorf("RenderContext() does not end with a single newline: %q", content)
+			}
+		})
+	}
+}
+
+func TestBuildManagedBlockBudget(t *testing.T) {
+	dir := chdirTemp(t)
+	path := filepath.Join(dir, "scratchpad.md")
+	old := time.Now().Add(-30 * 24 * time.Hour).Format(scratchpad.TimestampFormat)
+	long := strings.Repeat("a long sentence about the design ", 20)
+	entries := []scratchpad.Entry{
+		{ID: "aaaa0001", Timestamp: old, Kind: scratchpad.KindNote, Content: "first " + long},
+		{ID: "aaaa0002", Timestamp: old, Kind: scratchpad.KindDecision, Content: "pinned " + long, Pinned: true},
+		{ID: "aaaa0003", Timestamp: old, Kind: scratchpad.KindNote, Content: "tagged " + long, Tags: []string{"db"}},
+		{ID: "aaaa0004", Timestamp: old, Kind: scratchpad.KindNote, Content: "last " + long},
+	}
+	if err := os.WriteFile(path, []byte(scratchpad.FormatAll(entries)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	full, err := BuildManagedBlock(path, Options{})
+	if err != nil {
+		t.Fatalf("BuildManagedBlock() error = %v", err)
+	}
+	if len(full.Omitted) != 0 || strings.Contains(full.Content, "### Omitted") {
+		t.Errorf("unlimited budget omitted %d entries", len(full.Omitted))
+	}
+
+	// Room for the pinned entry and one and a half others
+	limit := budget.EstimateTokens(full.Content) - budget.EstimateTokens(long)*3/2
+	b := budget.Budget{Tokens: limit}
+	block, err := BuildManagedBlock(path, Options{Budget: b})
+	if err != nil {
+		t.Fatalf("BuildManagedBlock() error = %v", err)
+	}
+	if !b.Fits(block.Content) {
+		t.Errorf("block is %d tokens, over the budget of %d", b.Size(block.Content), limit)
+	}
+	for _, id := range []string{"aaaa0002", "aaaa0003"} {
+		if !strings.Contains(block.Content, id) {
+			t.Errorf("block does not contain %s", id)
+		}
+	}
+	var omitted []string
+	for _, e := range block.Omitted {
+		omitted = append(omitted, e.ID)
+	}
+	if strings.Join(omitted, " ") != "aaaa0001 aaaa0004" {
+		t.Errorf("Omitted = %v, expected [aaaa0001 aaaa0004]", omitted)
+	}
+	if !strings.Contains(block.Content, "2 entries left out to fit the context budget (2 notes)") {
+		t.Errorf("block does not summarize the omitted entries:\n%s", block.Content)
+	}
+
+	// Pinned entries are kept even when they alone exceed the budget
+	block, err = BuildManagedBlock(path, Options{Budget: budget.Budget{Tokens: 1}})
+	if err != nil {
+		t.Fatalf("BuildManagedBlock() error = %v", err)
+	}
+	if !strings.Contains(block.Content, "aaaa0002") || len(block.Omitted) != 3 {
+		t.Errorf("tiny budget kept %d entries, expected only the pinned one", 4-len(block.Omitted))
+	}
+}
+
+func TestBuildManagedBlockExpired(t *testing.T) {
+	dir := chdirTemp(t)
+	path := filepath.Join(dir, "scratchpad.md")
+	now := time.Now()
+	ts := now.Add(-48 * time.Hour).Format(scratchpad.TimestampFormat)
+	expired := now.Add(-time.Hour).Truncate(time.Second)
+	entries := []scratchpad.Entry{
+		{ID: "aaaa0001", Timestamp: ts, Kind: scratchpad.KindDecision, Content: "expired pinned", Pinned: true, Expires: expired},
+		{ID: "aaaa0002", Timestamp: ts, Kind: scratchpad.KindNote, Content: "expired note", Expires: expired},
+		{ID: "aaaa0003", Timestamp: ts, Kind: scratchpad.KindNote, Content: "live note", Expires: now.Add(time.Hour)},
+	}
+	if err := os.WriteFile(path, []byte(scratchpad.FormatAll(entries)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	block, err := BuildManagedBlock(path, Options{})
+	if err != nil {
+		t.Fatalf("BuildManagedBlock() error = %v", err)
+	}
+	for _, id := range []string{"aaaa0001", "aaaa0002"} {
+		if strings.Contains(block.Content, id) {
+			t.Errorf("block contains expired entry %s:\n%s", id, block.Content)
+		}
+	}
+	if !strings.Contains(block.Content, "aaaa0003") {
+		t.Errorf("block does not contain the unexpired entry:\n%s", block.Content)
+	}
+	if strings.Contains(block.Parts[BlockContext], "### Pinned") {
+		t.Errorf("block has a Pinned section with only an expired pinned entry")
+	}
+}
+
+func TestSuperseded(t *testing.T) {
+	tests := []struct {
+		name     string
+		entries  []scratchpad.Entry
+		expected []string
+	}{
+		{"none", []scratchpad.Entry{{ID: "a"}, {ID: "b"}}, nil},
+		{"both sides recorded", []scratchpad.Entry{
+			{ID: "a", SupersededBy: "b"},
+			{ID: "b", Supersedes: []string{"a"}},
+		}, []string{"a"}},
+		{"only the replacement records it", []scratchpad.Entry{
+			{ID: "a"},
+			{ID: "b", Supersedes: []string{"a"}},
+		}, []string{"a"}},
+		{"only the old entry records it", []scratchpad.Entry{
+			{ID: "a", SupersededBy: "b"},
+			{ID: "b"},
+		}, []string{"a"}},
+		{"several replaced", []scratchpad.Entry{
+			{ID: "a"},
+			{ID: "b"},
+			{ID: "c", Supersedes: []string{"a", "b"}},
+		}, []string{"a", "b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Superseded(tt.entries)
+			var ids []string
+			for _, e := range tt.entries {
+				if got[e.ID] {
+					ids = append(ids, e.ID)
+				}
+			}
+			if len(got) != len(tt.expected) || strings.Join(ids, " ") != strings.Join(tt.expected, " ") {
+				t.Errorf("Superseded() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestBuildManagedBlockSuperseded(t *testing.T) {
+	dir := chdirTemp(t)
+	path := filepath.Join(dir, "scratchpad.md")
+	ts := time.Now().Add(-48 * time.Hour).Format(scratchpad.TimestampFormat)
+	entries := []scratchpad.Entry{
+		{ID: "aaaa0001", Timestamp: ts, Kind: scratchpad.KindDecision, Content: "use MySQL", Pinned: true},
+		{ID: "aaaa0002", Timestamp: ts, Kind: scratchpad.KindDecision, Content: "use Postgres", SupersededBy: "aaaa0004"},
+		{ID: "aaaa0003", Timestamp: ts, Kind: scratchpad.KindDecision, Content: "use SQLite", Supersedes: []string{"aaaa0001"}},
+		{ID: "aaaa0004", Timestamp: ts, Kind: scratchpad.KindDecision, Content: "use CockroachDB"},
+	}
+	if err := os.WriteFile(path, []byte(scratchpad.FormatAll(entries)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	block, err := BuildManagedBlock(path, Options{})
+	if err != nil {
+		t.Fatalf("BuildManagedBlock() error = %v", err)
+	}
+	context := block.Parts[BlockContext]
+	for _, content := range []string{"use MySQL", "use Postgres"} {
+		if strings.Contains(context, content) {
+			t.Errorf("block contains superseded entry %q:\n%s", content, context)
+		}
+	}
+	for _, content := range []string{"use SQLite", "use CockroachDB"} {
+		if !strings.Contains(context, content) {
+			t.Errorf("block does not contain %q:\n%s", content, context)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+// Package todo stores the project's task list in .aipad/todos.json.
+package todo
+
+import (
+	"aipad/internal/state"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	TodoFile = "todos.json"
+
+	StatusOpen = "open"
+	StatusDone = "done"
+)
+
+// Item is a single task
+type Item struct {
+	ID        string    `json:"id"`
+	Text      string    `json:"text"`
+	Status    string    `json:"status"`
+	Owner     string    `json:"owner,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	// CreatedBy and DoneBy record who added and closed the item, e.g. "alice via claude-code"
+	CreatedBy string `json:"created_by,omitempty"`
+	// Entries lists the IDs of scratchpad entries the item relates to
+	Entries []string   `json:"entries,omitempty"`
+	DoneAt  *time.Time `json:"done_at,omitempty"`
+	DoneBy  string     `json:"done_by,omitempty"`
+}
+
+// List is the task list
+type List struct {
+	Items []Item `json:"items"`
+}
+
+// GetTodoPath returns the path to the todos.json file
+func GetTodoPath() (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cwd, state.AIPadDir, TodoFile), nil
+}
+
+// Load reads the task list, returning an empty list if none exists yet
+func Load() (*List, error) {
+	path, err := GetTodoPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &List{Items: []Item{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var l List
+	if err := json.Unmarshal(data, &l); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", TodoFile, err)
+	}
+	return &l, nil
+}
+
+// Save writes the task list to disk
+func (l *List) Save() error {
+	path, err := GetTodoPath()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
+
+// Add appends an open item and returns it.
+// The returned pointer is valid until the next item is added.
+func (l *List) Add(text, owner string, entries []string) *Item {
+	l.Items = append(l.Items, Item{
+		ID:        l.newID(),
+		Text:      text,
+		Status:    StatusOpen,
+		Owner:     owner,
+		CreatedAt: time.Now(),
+		Entries:   entries,
+	})
+	return &l.Items[len(l.Items)-1]
+}
+
+// Find returns the index of the item with the given ID.
+// A unique prefix of an ID is also accepted.
+func (l *List) Find(id string) (int, error) {
+	if id == "" {
+		return -1, fmt.Errorf("todo ID cannot be empty")
+	}
+	found := -1
+	for i, item := range l.Items {
+		if item.ID == id {
+			return i, nil
+		}
+		if strings.HasPrefix(item.ID, id) {
+			if found >= 0 {
+				return -1, fmt.Errorf("todo ID '%s' is ambiguous", id)
+			}
+			found = i
+		}
+	}
+	if found < 0 {
+		return -1, fmt.Errorf("todo '%s' not found", id)
+	}
+	return found, nil
+}
+
+// Done marks the item at index i as done by the given person
+func (l *List) Done(i int, by string) {
+	now := time.Now()
+	l.Items[i].Status = StatusDone
+	l.Items[i].DoneAt = &now
+	l.Items[i].DoneBy = by
+}
+
+// Open returns the items that are not done yet, oldest first
+func (l *List) Open() []Item {
+	var open []Item
+	for _, item := range l.Items {
+		if item.Status != StatusDone {
+			open = append(open, item)
+		}
+	}
+	return open
+}
+
+// newID generates a short random ID that is unique within the list
+func (l *List) newID() string {
+	for {
+		id := strings.ReplaceAll(uuid.New().String(), "-", "")[:state.EntryIDLength]
+		if _, err := l.Find(id); err != nil {
+			return id
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package todo
+
+import "testing"
+
+func TestFind(t *testing.T) {
+	l := &List{Items: []Item{
+		{ID: "3f2a9c1d", Text: "one"},
+		{ID: "3f2b0000", Text: "two"},
+		{ID: "7b1e04c2", Text: "three"},
+	}}
+
+	tests := []struct {
+		name     string
+		id       string
+		expected int
+		wantErr  bool
+	}{
+		{name: "exact", id: "7b1e04c2", expected: 2},
+		{name: "unique prefix", id: "3f2b", expected: 1},
+		{name: "ambiguous prefix", id: "3f2", wantErr: true},
+		{name: "missing", id: "ffff", wantErr: true},
+		{name: "empty", id: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i, err := l.Find(tt.id)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Find(%q) error = %v, wantErr %v", tt.id, err, tt.wantErr)
+			}
+			if !tt.wantErr && i != tt.expected {
+				t.Errorf("Find(%q) = %d, want %d", tt.id, i, tt.expected)
+			}
+		})
+	}
+}
+
+func TestOpen(t *testing.T) {
+	l := &List{}
+	first := l.Add("first", "", nil).ID
+	l.Add("second", "alice", []string{"3f2a9c1d"})
+	l.Add("third", "", nil)
+
+	i, err := l.Find(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Done(i, "bob")
+
+	open := l.Open()
+	if len(open) != 2 || open[0].Text != "second" || open[1].Text != "third" {
+		t.Fatalf("Open() = %+v, want second and third", open)
+	}
+	if l.Items[i].Status != StatusDone || l.Items[i].DoneAt == nil || l.Items[i].DoneBy != "bob" {
+		t.Errorf("Done() left %+v, want status done by bob", l.Items[i])
+	}
+}
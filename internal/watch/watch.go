@@ -0,0 +1,144 @@
+package watch
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"os"
+	"slices"
+	"time"
+)
+
+// fileState is what the poller remembers about a file between polls
+type fileState struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+	sum     [sha256.Size]byte
+}
+
+// Poller detects changes to a set of files by polling them. A file counts as
+// changed when it appears, disappears or its content differs; touching a
+// file without changing it is not a change.
+type Poller struct {
+	paths []string
+	last  map[string]fileState
+	// expected holds content the caller wrote itself, which the next call to
+	// Changed does not report
+	expected map[string][sha256.Size]byte
+}
+
+// NewPoller returns a poller that reports changes from the files' current state
+func NewPoller(paths ...string) *Poller {
+	p := &Poller{paths: paths, last: make(map[string]fileState), expected: make(map[string][sha256.Size]byte)}
+	p.Reset()
+	return p
+}
+
+// Reset records the files' current state so earlier changes are not reported
+func (p *Poller) Reset() {
+	for _, path := range p.paths {
+		p.last[path] = stat(path, p.last[path])
+	}
+}
+
+// Expect notes that the caller wrote data to path, so the next call to
+// Changed does not report path if it still holds data. A write by anyone
+// else after it is still reported.
+func (p *Poller) Expect(path string, data []byte) {
+	p.expected[path] = sha256.Sum256(data)
+}
+
+// Changed returns the paths that changed since the previous call or Reset,
+// except those holding the content passed to Expect
+func (p *Poller) Changed() []string {
+	var changed []string
+	for _, path := range p.paths {
+		prev := p.last[path]
+		cur := stat(path, prev)
+		sum, expected := p.expected[path]
+		if cur.exists != prev.exists || !bytes.Equal(cur.sum[:], prev.sum[:]) {
+			if !expected || !cur.exists || cur.sum != sum {
+				changed = append(changed, path)
+			}
+		}
+		p.last[path] = cur
+	}
+	clear(p.expected)
+	return changed
+}
+
+// stat reads the state of path. The content is only hashed again when the
+// size or modification time differ from prev.
+func stat(path string, prev fileState) fileState {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileState{}
+	}
+	cur := fileState{exists: true, size: info.Size(), modTime: info.ModTime()}
+	if prev.exists && cur.size == prev.size && cur.modTime.Equal(prev.modTime) {
+		cur.sum = prev.sum
+		return cur
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fileState{}
+	}
+	cur.sum = sha256.Sum256(data)
+	return cur
+}
+
+// Watch polls the poller every interval and calls fn with the changed paths
+// once they have been quiet for the debounce period, so a burst of writes
+// causes a single call. Files that change while fn runs are passed to the
+// next call, unless fn wrote them itself and told the poller with Expect.
+// When ctx is done, changes still waiting for their debounce period are
+// passed to fn before Watch returns.
+func Watch(ctx context.Context, p *Poller, interval, debounce time.Duration, fn func(changed []string)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var pending []string
+	var lastChange time.Time
+	flush := func() {
+		if len(pending) == 0 {
+			return
+		}
+		// Anything written since the last poll is covered by this call
+		fn(merge(pending, p.Changed()))
+		pending = nil
+		// Anything written while fn ran is not
+		if changed := p.Changed(); len(changed) > 0 {
+			pending = changed
+			lastChange = time.Now()
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			// Pick up anything written since the last poll
+			pending = merge(pending, p.Changed())
+			flush()
+			return
+		case now := <-ticker.C:
+			if changed := p.Changed(); len(changed) > 0 {
+				pending = merge(pending, changed)
+				lastChange = now
+			}
+			if len(pending) > 0 && now.Sub(lastChange) >= debounce {
+				flush()
+			}
+		}
+	}
+}
+
+// merge adds the paths in more to paths, skipping those already present
+func merge(paths, more []string) []string {
+	for _, path := range more {
+		if !slices.Contains(paths, path) {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
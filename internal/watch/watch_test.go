@@ -0,0 +1,122 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPoller(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.md")
+	b := filepath.Join(dir, "b.md")
+	os.WriteFile(a, []byte("one"), 0644)
+
+	p := NewPoller(a, b)
+	tests := []struct {
+		name     string
+		change   func()
+		expected []string
+	}{
+		{"nothing", func() {}, nil},
+		{"content", func() { os.WriteFile(a, []byte("two"), 0644) }, []string{a}},
+		{"created", func() { os.WriteFile(b, []byte("new"), 0644) }, []string{b}},
+		{"touched only", func() {
+			later := time.Now().Add(time.Hour)
+			os.Chtimes(a, later, later)
+		}, nil},
+		{"removed", func() { os.Remove(a) }, []string{a}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.change()
+			if got := p.Changed(); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Changed() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWatchDebounce(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "scratchpad.md")
+	os.WriteFile(path, []byte("0"), 0644)
+
+	var mu sync.Mutex
+	calls := 0
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	p := NewPoller(path)
+	go func() {
+		Watch(ctx, p, 5*time.Millisecond, 100*time.Millisecond, func(changed []string) {
+			mu.Lock()
+			calls++
+			mu.Unlock()
+			// Writes fn expects are not reported
+			p.Expect(path, []byte("written by fn"))
+			os.WriteFile(path, []byte("written by fn"), 0644)
+		})
+		close(done)
+	}()
+
+	// A burst of writes shorter than the debounce period causes one call
+	for i := 1; i <= 5; i++ {
+		os.WriteFile(path, []byte{byte('0' + i)}, 0644)
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(300 * time.Millisecond)
+	mu.Lock()
+	if calls != 1 {
+		t.Errorf("calls after a burst = %d, expected 1", calls)
+	}
+	mu.Unlock()
+
+	// A change still waiting for its debounce period is flushed on shutdown
+	os.WriteFile(path, []byte("last"), 0644)
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	<-done
+	if calls != 2 {
+		t.Errorf("calls after shutdown = %d, expected 2", calls)
+	}
+}
+
+func TestWatchChangeDuringCall(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "scratchpad.md")
+	os.WriteFile(path, []byte("0"), 0644)
+
+	var mu sync.Mutex
+	var calls [][]string
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	p := NewPoller(path)
+	go func() {
+		Watch(ctx, p, 5*time.Millisecond, 20*time.Millisecond, func(changed []string) {
+			mu.Lock()
+			calls = append(calls, changed)
+			first := len(calls) == 1
+			mu.Unlock()
+			if first {
+				// Another process writes while the first call runs
+				os.WriteFile(path, []byte("written during the call"), 0644)
+			}
+		})
+		close(done)
+	}()
+
+	os.WriteFile(path, []byte("1"), 0644)
+	time.Sleep(200 * time.Millisecond)
+	cancel()
+	<-done
+
+	expected := [][]string{{path}, {path}}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("calls = %v, expected %v", calls, expected)
+	}
+}